@@ -0,0 +1,15 @@
+// Package docs embeds the rule documentation so it is available offline, e.g. to the explain command.
+package docs
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed rules/*.md
+var rules embed.FS
+
+// Rule returns the markdown documentation of the named rule.
+func Rule(name string) ([]byte, error) {
+	return fs.ReadFile(rules, "rules/"+name+".md")
+}
@@ -0,0 +1,130 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// fixedJSON applies the fixes made to the dashboard model to the JSON it was parsed from. The
+// fixes are turned into JSON Patch operations by comparing the model before and after fixing,
+// and applied as minimal edits, so the formatting, key order and properties which are not part
+// of the model are kept.
+//
+// Kubernetes shaped dashboards are fixed in their spec, so the apiVersion, kind and metadata of
+// the resource are kept as they are. The operations on v2 dashboards are translated to the v2
+// schema, and those on a library panel linted on its own to its model.
+func fixedJSON(before []byte, dashboard Dashboard, buf []byte) ([]byte, error) {
+	after, err := dashboard.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	var a, b map[string]interface{}
+	var doc interface{}
+	for _, v := range []struct {
+		buf []byte
+		out interface{}
+	}{{before, &a}, {after, &b}, {buf, &doc}} {
+		if err := json.Unmarshal(v.buf, v.out); err != nil {
+			return nil, err
+		}
+	}
+	if dashboard.Spec != nil {
+		// The model of a kubernetes shaped dashboard is its spec, next to the resource's
+		// apiVersion and raw spec.
+		for _, m := range []map[string]interface{}{a, b} {
+			delete(m, "apiVersion")
+			delete(m, "spec")
+		}
+	}
+
+	ops := diffJSON(nil, a, b)
+	// fixed is the fixed model in the shape of the document, to add missing parents from.
+	var fixed interface{}
+	switch {
+	case dashboard.v2 != nil:
+		// Fixes which cannot be written to the v2 document are left out, see dropUnsupportedFixes.
+		if ops, _, err = dashboard.v2.translate(ops); err != nil {
+			return nil, err
+		}
+	case dashboard.library != nil:
+		if fixed, err = dashboard.library.translate(ops, b); err != nil {
+			return nil, err
+		}
+	case dashboard.raw == nil:
+		return nil, fmt.Errorf("autofix is not supported for dashboards with apiVersion '%s'", dashboard.APIVersion)
+	case dashboard.Spec != nil:
+		for i := range ops {
+			ops[i].Path = "/spec" + ops[i].Path
+		}
+		fixed = map[string]interface{}{"spec": b}
+	default:
+		fixed = b
+	}
+
+	if ops, err = adaptPatch(doc, fixed, ops); err != nil {
+		return nil, err
+	}
+	return patchBytes(buf, ops)
+}
+
+// adaptPatch adapts operations computed on the model to the document. The model has defaults for
+// properties the document may not have, so values replaced in missing members are added
+// instead, along with any missing parents taken from fixed, and removing a missing member does
+// nothing.
+func adaptPatch(doc, fixed interface{}, ops []PatchOperation) ([]PatchOperation, error) {
+	var adapted []PatchOperation
+	var added []string
+	for _, op := range ops {
+		if underPointer(op.Path, added) {
+			continue
+		}
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		n := existingTokens(doc, tokens)
+		switch {
+		case n == len(tokens):
+		case op.Op == "remove":
+			continue
+		case n == len(tokens)-1:
+			if op.Op == "replace" {
+				op.Op = "add"
+			}
+		default:
+			path := tokens[:n+1]
+			value, err := patchGet(fixed, path)
+			if err != nil {
+				return nil, fmt.Errorf("cannot apply fix to '%s': %w", op.Path, err)
+			}
+			op = PatchOperation{Op: "add", Path: formatPointer(path), Value: value}
+			added = append(added, op.Path)
+		}
+		if doc, err = applyPatch(doc, []PatchOperation{op}); err != nil {
+			return nil, err
+		}
+		adapted = append(adapted, op)
+	}
+	return adapted, nil
+}
+
+// existingTokens returns how many of the pointer tokens exist in the document.
+func existingTokens(doc interface{}, tokens []string) int {
+	for i := range tokens {
+		if _, err := patchGet(doc, tokens[:i+1]); err != nil {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// underPointer reports whether the pointer is one of the parents, or nested in one of them.
+func underPointer(pointer string, parents []string) bool {
+	for _, p := range parents {
+		if pointer == p || strings.HasPrefix(pointer, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -9,12 +9,23 @@ import (
 )
 
 // ConfigurationFile contains a map for rule exclusions, and warnings, where the key is the
-// rule name to be excluded or downgraded to a warning
+// rule name to be excluded or downgraded to a warning. It may also declare custom rules and rule plugins.
 type ConfigurationFile struct {
 	Exclusions map[string]*ConfigurationRuleEntries `yaml:"exclusions"`
 	Warnings   map[string]*ConfigurationRuleEntries `yaml:"warnings"`
+	Rules      []CustomRuleConfig                   `yaml:"rules"`
+	Plugins    []PluginConfig                       `yaml:"plugins"`
 	Verbose    bool                                 `yaml:"-"`
 	Autofix    bool                                 `yaml:"-"`
+	// DryRun makes Autofix only compute the fixed dashboard. The results it would fix are
+	// reported as they are, and counted as available fixes.
+	DryRun bool `yaml:"-"`
+	// Hints appends a pointer to the explain command after the findings of each rule.
+	Hints bool `yaml:"-"`
+	// GroupBy selects how the TTY reporter groups results, by rule if empty.
+	GroupBy GroupBy `yaml:"-"`
+	// Summary makes the TTY reporter end with a table of result counts.
+	Summary bool `yaml:"-"`
 }
 
 type ConfigurationRuleEntries struct {
@@ -77,8 +88,10 @@ func (cf *ConfigurationFile) Apply(res ResultContext) ResultContext {
 		}
 		if matched {
 			for i, r := range res.Result.Results {
+				// Excluded results are accepted as they are, so they are not fixed.
 				r.Severity = Exclude
 				r.Message += " (Excluded)"
+				r.Fix = nil
 				res.Result.Results[i] = r
 			}
 		}
@@ -101,7 +114,9 @@ func (cf *ConfigurationFile) Apply(res ResultContext) ResultContext {
 		}
 		if matched {
 			for i, r := range res.Result.Results {
+				// Downgraded results are accepted as well, so they are not fixed either.
 				r.Severity = Warning
+				r.Fix = nil
 				res.Result.Results[i] = r
 			}
 		}
@@ -141,3 +156,23 @@ func (cf *ConfigurationFile) Load(path string) error {
 	}
 	return nil
 }
+
+// CustomRules builds the custom rules and rule plugins declared in the configuration.
+func (cf *ConfigurationFile) CustomRules() ([]Rule, error) {
+	rules := make([]Rule, 0, len(cf.Rules)+len(cf.Plugins))
+	for _, c := range cf.Rules {
+		r, err := NewCustomRule(c)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	for _, c := range cf.Plugins {
+		r, err := NewPluginRule(c)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
@@ -0,0 +1,236 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+// CustomRuleConfig declares a rule in the configuration file. The rule's check is a CEL
+// expression which must evaluate to true for every dashboard, panel, target or template
+// in its scope. See https://github.com/google/cel-spec for the expression language.
+//
+// The expression can use the following variables, depending on the scope:
+//   - dashboard: the dashboard JSON (all scopes)
+//   - panel: the panel JSON (panel and target scope)
+//   - target: the target JSON (target scope)
+//   - template: the template variable JSON (template scope)
+//   - datasource: the datasource of the panel, target or template, as {"uid": ..., "type": ...}
+//   - query: the query of the template variable, or the expression of the target
+type CustomRuleConfig struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Scope       Category `yaml:"scope"`
+	Severity    Severity `yaml:"severity"`
+	Expression  string   `yaml:"expression"`
+	// Message is reported when the expression evaluates to false.
+	Message string `yaml:"message"`
+	// Weight is how much the rule counts towards the quality score, 1 by default.
+	Weight float64 `yaml:"weight"`
+}
+
+type customRule struct {
+	config  CustomRuleConfig
+	program cel.Program
+}
+
+// NewCustomRule compiles the expression of a custom rule.
+func NewCustomRule(c CustomRuleConfig) (Rule, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("custom rule is missing a name")
+	}
+	if c.Severity == Success {
+		c.Severity = Error
+	}
+	if c.Severity != Error && c.Severity != Warning {
+		return nil, fmt.Errorf("custom rule '%s' has invalid severity '%s', must be error or warning", c.Name, c.Severity)
+	}
+	if c.Message == "" {
+		c.Message = fmt.Sprintf("does not satisfy '%s'", c.Expression)
+	}
+
+	vars := []cel.EnvOption{
+		cel.Variable("dashboard", cel.MapType(cel.StringType, cel.DynType)),
+	}
+	switch c.Scope {
+	case CategoryDashboard:
+	case CategoryPanel:
+		vars = append(vars,
+			cel.Variable("panel", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("datasource", cel.MapType(cel.StringType, cel.StringType)),
+		)
+	case CategoryTarget:
+		vars = append(vars,
+			cel.Variable("panel", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("target", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("datasource", cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable("query", cel.StringType),
+		)
+	case CategoryTemplate:
+		vars = append(vars,
+			cel.Variable("template", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("datasource", cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable("query", cel.StringType),
+		)
+	default:
+		return nil, fmt.Errorf("custom rule '%s' has invalid scope '%s', must be one of dashboard, panel, target or template", c.Name, c.Scope)
+	}
+
+	env, err := cel.NewEnv(vars...)
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(c.Expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("custom rule '%s' has invalid expression: %w", c.Name, iss.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("custom rule '%s' expression must evaluate to a bool, not %s", c.Name, ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("custom rule '%s': %w", c.Name, err)
+	}
+	return &customRule{config: c, program: program}, nil
+}
+
+func (r *customRule) Name() string        { return r.config.Name }
+func (r *customRule) Description() string { return r.config.Description }
+func (r *customRule) Metadata() Metadata {
+	return Metadata{
+		Category: r.config.Scope,
+		Severity: r.config.Severity,
+		Weight:   r.config.Weight,
+	}
+}
+
+func (r *customRule) Lint(d Dashboard, s *ResultSet) {
+	dashboard := rawDashboard(d)
+	vars := map[string]interface{}{"dashboard": dashboard}
+
+	switch r.config.Scope {
+	case CategoryDashboard:
+		r.addResult(s, d, nil, nil, vars, dashboardMessage(d, r.config.Message))
+	case CategoryTemplate:
+		templates := rawNodes(dashboard, d.Templating.List, "templating", "list")
+		for i, t := range d.Templating.List {
+			ds, _ := t.GetDataSource()
+			vars["template"] = templates[i]
+			vars["datasource"] = datasourceVar(ds)
+			vars["query"] = t.Query
+			r.addResult(s, d, nil, nil, vars, dashboardMessage(d, fmt.Sprintf("template '%s' %s", t.Name, r.config.Message)))
+		}
+	case CategoryPanel, CategoryTarget:
+		panels := d.GetPanels()
+		rawPanels := rawNodes(rawPanelList(dashboard), panels)
+		for pi, p := range panels {
+			p := p // capture loop variable
+			vars["panel"] = rawPanels[pi]
+			pds, _ := p.GetDataSource()
+			if r.config.Scope == CategoryPanel {
+				vars["datasource"] = datasourceVar(pds)
+				r.addResult(s, d, &p, nil, vars, panelMessage(d, p, r.config.Message))
+				continue
+			}
+			targets := rawNodes(rawPanels[pi], p.Targets, "targets")
+			for ti, t := range p.Targets {
+				t := t // capture loop variable
+				ds, _ := t.GetDataSource()
+				if ds.UID == "" {
+					ds = pds
+				}
+				vars["target"] = targets[ti]
+				vars["datasource"] = datasourceVar(ds)
+				vars["query"] = t.Expr
+				r.addResult(s, d, &p, &t, vars, targetMessage(d, p, t, r.config.Message))
+			}
+		}
+	}
+}
+
+func (r *customRule) addResult(s *ResultSet, d Dashboard, p *Panel, t *Target, vars map[string]interface{}, message string) {
+	result := ResultSuccess
+	out, _, err := r.program.Eval(vars)
+	if err != nil {
+		result = Result{Severity: Error, Message: fmt.Sprintf("%s (evaluation failed: %v)", message, err)}
+	} else if ok, _ := out.Value().(bool); !ok {
+		result = Result{Severity: r.config.Severity, Message: message}
+	}
+	s.AddResult(ResultContext{
+		Result:    RuleResults{[]FixableResult{{Result: result}}},
+		Rule:      r,
+		Dashboard: &d,
+		Panel:     p,
+		Target:    t,
+	})
+}
+
+func datasourceVar(ds Datasource) map[string]string {
+	return map[string]string{"uid": ds.UID, "type": ds.Type}
+}
+
+// rawDashboard returns the JSON object the dashboard was parsed from. Dashboards which were not
+// parsed from classic dashboard JSON (e.g. v2 dashboards, or ones built in code), or which have
+// library panels resolved, are marshalled from the model instead.
+func rawDashboard(d Dashboard) map[string]interface{} {
+	buf := d.raw
+	if buf == nil || d.libraryPanels != nil {
+		buf, _ = d.Marshal()
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf, &m); err != nil || m == nil {
+		m = map[string]interface{}{}
+	}
+	return m
+}
+
+// rawPanelList returns the panel objects of a raw dashboard, in the same order as Dashboard.GetPanels.
+func rawPanelList(dashboard map[string]interface{}) []interface{} {
+	var panels []interface{}
+	var walk func(interface{})
+	walk = func(v interface{}) {
+		list, _ := v.([]interface{})
+		for _, p := range list {
+			panels = append(panels, p)
+			if m, ok := p.(map[string]interface{}); ok {
+				walk(m["panels"])
+			}
+		}
+	}
+	rows, _ := dashboard["rows"].([]interface{})
+	for _, row := range rows {
+		if m, ok := row.(map[string]interface{}); ok {
+			walk(m["panels"])
+		}
+	}
+	walk(dashboard["panels"])
+	return panels
+}
+
+// rawNodes returns one raw JSON object per parsed node. The objects are looked up by following
+// path from the given root, and fall back to the marshalled node when the raw JSON and the
+// parsed model disagree.
+func rawNodes[T any](root interface{}, parsed []T, path ...string) []map[string]interface{} {
+	for _, key := range path {
+		m, _ := root.(map[string]interface{})
+		root = m[key]
+	}
+	list, _ := root.([]interface{})
+
+	nodes := make([]map[string]interface{}, len(parsed))
+	for i := range parsed {
+		if len(list) == len(parsed) {
+			if m, ok := list[i].(map[string]interface{}); ok {
+				nodes[i] = m
+				continue
+			}
+		}
+		buf, _ := json.Marshal(parsed[i])
+		_ = json.Unmarshal(buf, &nodes[i])
+		if nodes[i] == nil {
+			nodes[i] = map[string]interface{}{}
+		}
+	}
+	return nodes
+}
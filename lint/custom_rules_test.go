@@ -0,0 +1,181 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const customRulesDashboard = `{
+	"title": "test",
+	"tags": ["team:observability"],
+	"templating": {
+		"list": [
+			{ "name": "datasource", "type": "datasource", "query": "prometheus" },
+			{ "name": "job", "type": "query", "datasource": "$datasource", "query": "label_values(up, job)", "hide": 2 }
+		]
+	},
+	"rows": [
+		{ "panels": [ { "id": 1, "type": "stat", "title": "legacy", "options": { "colorMode": "value" } } ] }
+	],
+	"panels": [
+		{ "id": 2, "type": "stat", "title": "stat", "datasource": "$datasource",
+		  "targets": [ { "refId": "A", "expr": "up", "legendFormat": "{{job}}" } ] },
+		{ "id": 3, "type": "row", "title": "row", "panels": [
+			{ "id": 4, "type": "timeseries", "title": "nested", "targets": [ { "refId": "A", "expr": "up", "datasource": "other" } ] }
+		] }
+	]
+}`
+
+func TestCustomRuleConfiguration(t *testing.T) {
+	var c ConfigurationFile
+	err := yaml.Unmarshal([]byte(`
+rules:
+  - name: dashboard-team-tag
+    description: Checks that the dashboard has a team tag.
+    scope: dashboard
+    severity: warning
+    expression: dashboard.tags.exists(t, t.startsWith("team:"))
+`), &c)
+	require.NoError(t, err)
+	require.Equal(t, []CustomRuleConfig{{
+		Name:        "dashboard-team-tag",
+		Description: "Checks that the dashboard has a team tag.",
+		Scope:       CategoryDashboard,
+		Severity:    Warning,
+		Expression:  `dashboard.tags.exists(t, t.startsWith("team:"))`,
+	}}, c.Rules)
+
+	rules, err := c.CustomRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, Metadata{Category: CategoryDashboard, Severity: Warning, Weight: 1}, GetMetadata(rules[0]))
+}
+
+func TestCustomRules(t *testing.T) {
+	d, err := NewDashboard([]byte(customRulesDashboard))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		config   CustomRuleConfig
+		expected []Result
+	}{
+		{
+			name: "dashboard",
+			config: CustomRuleConfig{
+				Scope:      CategoryDashboard,
+				Expression: `dashboard.tags.exists(t, t.startsWith("team:"))`,
+			},
+			expected: []Result{ResultSuccess},
+		},
+		{
+			name: "dashboard failure with message",
+			config: CustomRuleConfig{
+				Scope:      CategoryDashboard,
+				Severity:   Warning,
+				Expression: `"timezone" in dashboard`,
+				Message:    "has no timezone",
+			},
+			expected: []Result{{Severity: Warning, Message: "Dashboard 'test' has no timezone"}},
+		},
+		{
+			name: "panel uses raw fields in rows and nested panels",
+			config: CustomRuleConfig{
+				Scope:      CategoryPanel,
+				Expression: `panel.type != "stat" || (has(panel.options) && has(panel.options.colorMode))`,
+				Message:    "does not set colorMode",
+			},
+			expected: []Result{
+				ResultSuccess,
+				{Severity: Error, Message: "Dashboard 'test', panel 'stat' does not set colorMode"},
+				ResultSuccess,
+				ResultSuccess,
+			},
+		},
+		{
+			name: "target with parsed datasource",
+			config: CustomRuleConfig{
+				Scope:      CategoryTarget,
+				Expression: `datasource.uid == "$datasource" && has(target.legendFormat) && query == "up"`,
+			},
+			expected: []Result{
+				ResultSuccess,
+				{Severity: Error, Message: `Dashboard 'test', panel 'nested', target idx '0' does not satisfy 'datasource.uid == "$datasource" && has(target.legendFormat) && query == "up"'`},
+			},
+		},
+		{
+			name: "template",
+			config: CustomRuleConfig{
+				Scope:      CategoryTemplate,
+				Expression: `template.type != "query" || (datasource.uid == "$datasource" && query.startsWith("label_values") && template.hide == 2)`,
+			},
+			expected: []Result{ResultSuccess, ResultSuccess},
+		},
+		{
+			name: "evaluation error",
+			config: CustomRuleConfig{
+				Scope:      CategoryDashboard,
+				Expression: `dashboard.missing == "x"`,
+				Message:    "fails",
+			},
+			expected: []Result{{Severity: Error, Message: "Dashboard 'test' fails (evaluation failed: no such key: missing)"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Name = "custom"
+			rule, err := NewCustomRule(tc.config)
+			require.NoError(t, err)
+
+			rs := ResultSet{}
+			rule.Lint(d, &rs)
+			var actual []Result
+			for _, rc := range rs.results {
+				for _, r := range rc.Result.Results {
+					actual = append(actual, r.Result)
+				}
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestInvalidCustomRules(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config CustomRuleConfig
+		err    string
+	}{
+		{
+			name:   "missing name",
+			config: CustomRuleConfig{Scope: CategoryDashboard, Expression: "true"},
+			err:    "custom rule is missing a name",
+		},
+		{
+			name:   "invalid scope",
+			config: CustomRuleConfig{Name: "r", Scope: "row", Expression: "true"},
+			err:    "custom rule 'r' has invalid scope 'row', must be one of dashboard, panel, target or template",
+		},
+		{
+			name:   "invalid severity",
+			config: CustomRuleConfig{Name: "r", Scope: CategoryDashboard, Severity: Fixed, Expression: "true"},
+			err:    "custom rule 'r' has invalid severity 'fixed', must be error or warning",
+		},
+		{
+			name:   "variable not in scope",
+			config: CustomRuleConfig{Name: "r", Scope: CategoryDashboard, Expression: `panel.type == "stat"`},
+			err:    "custom rule 'r' has invalid expression",
+		},
+		{
+			name:   "not a bool",
+			config: CustomRuleConfig{Name: "r", Scope: CategoryDashboard, Expression: `"foo"`},
+			err:    "custom rule 'r' expression must evaluate to a bool, not string",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewCustomRule(tc.config)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package lint
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// UnifiedDiff returns a unified diff from the original to the fixed dashboard JSON, labelled with
+// the name of the dashboard file. It is empty if nothing was fixed.
+func UnifiedDiff(name string, original, fixed []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(original)),
+		B:        splitLines(string(fixed)),
+		FromFile: path.Join("a", name),
+		ToFile:   path.Join("b", name),
+		Context:  3,
+	})
+}
+
+// splitLines splits text into lines which all end with a newline, unlike difflib.SplitLines it
+// does not add an empty line after a trailing newline. A last line without a newline is followed
+// by the standard marker, so a fix of the trailing newline shows as a change.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	return lines
+}
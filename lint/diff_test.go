@@ -0,0 +1,59 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	original := "{\n  \"title\": \"test\",\n  \"editable\": true,\n  \"panels\": []\n}\n"
+	fixed := "{\n  \"title\": \"test\",\n  \"editable\": false,\n  \"panels\": []\n}\n"
+
+	diff, err := UnifiedDiff("dashboard.json", []byte(original), []byte(fixed))
+	require.NoError(t, err)
+	require.Equal(t, `--- a/dashboard.json
++++ b/dashboard.json
+@@ -1,5 +1,5 @@
+ {
+   "title": "test",
+-  "editable": true,
++  "editable": false,
+   "panels": []
+ }
+`, diff)
+
+	diff, err = UnifiedDiff("dashboard.json", []byte(original), []byte(original))
+	require.NoError(t, err)
+	require.Empty(t, diff)
+}
+
+func TestUnifiedDiffNoNewlineAtEnd(t *testing.T) {
+	original := "{\n  \"editable\": true\n}"
+	fixed := "{\n  \"editable\": false\n}"
+
+	diff, err := UnifiedDiff("dashboard.json", []byte(original), []byte(fixed))
+	require.NoError(t, err)
+	require.Equal(t, `--- a/dashboard.json
++++ b/dashboard.json
+@@ -1,3 +1,3 @@
+ {
+-  "editable": true
++  "editable": false
+ }
+\ No newline at end of file
+`, diff)
+
+	// Adding the trailing newline is a change.
+	diff, err = UnifiedDiff("dashboard.json", []byte(original), []byte(original+"\n"))
+	require.NoError(t, err)
+	require.Equal(t, `--- a/dashboard.json
++++ b/dashboard.json
+@@ -1,3 +1,3 @@
+ {
+   "editable": true
+-}
+\ No newline at end of file
++}
+`, diff)
+}
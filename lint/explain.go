@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/grafana/dashboard-linter/docs"
+)
+
+// ExplainHint returns a short hint pointing to the explain command of the named rule.
+func ExplainHint(rule string) string {
+	return fmt.Sprintf("Run 'dashboard-linter explain %s' for details.", rule)
+}
+
+// Explain writes the documentation of the named rule to w. It only uses documentation
+// embedded in the binary, so it works without network access.
+func (s *RuleSet) Explain(w io.Writer, name string) error {
+	var rule Rule
+	for _, r := range s.rules {
+		if r.Name() == name {
+			rule = r
+			break
+		}
+	}
+	if rule == nil {
+		return fmt.Errorf("unknown rule '%s', run 'dashboard-linter rules' to list all rules", name)
+	}
+
+	m := GetMetadata(rule)
+	datasources := "all"
+	if len(m.Datasources) > 0 {
+		datasources = strings.Join(m.Datasources, ", ")
+	}
+	fixable := "no"
+	if m.Fixable {
+		fixable = "yes, with --fix"
+	}
+
+	_, _ = fmt.Fprintf(w, "%s - %s\n\n", rule.Name(), rule.Description())
+	_, _ = fmt.Fprintf(w, "Category:    %s\n", m.Category)
+	_, _ = fmt.Fprintf(w, "Severity:    %s\n", m.Severity)
+	_, _ = fmt.Fprintf(w, "Datasources: %s\n", datasources)
+	_, _ = fmt.Fprintf(w, "Fixable:     %s\n", fixable)
+	if m.Rationale != "" {
+		_, _ = fmt.Fprintf(w, "\nRationale: %s\n", m.Rationale)
+	}
+
+	doc, err := docs.Rule(rule.Name())
+	if err == nil {
+		_, _ = fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(string(doc)))
+	} else if m.DocsURL != "" {
+		_, _ = fmt.Fprintf(w, "\nSee %s\n", m.DocsURL)
+	}
+
+	_, _ = fmt.Fprintf(w, "\n# Configuration\n")
+	_, _ = fmt.Fprintf(w, "This rule has no options. Its results can be excluded, or downgraded to warnings, "+
+		"in the .lint file next to the dashboard:\n\n")
+	_, _ = fmt.Fprintf(w, "```yaml\n%s```\n\n", exclusionExample(rule.Name(), m.Category))
+	_, _ = fmt.Fprintf(w, "Replace 'exclusions' with 'warnings' to report the results as warnings instead. "+
+		"Omit 'entries' to exclude the rule for every dashboard.\n")
+	return nil
+}
+
+func exclusionExample(name string, category Category) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "exclusions:\n  %s:\n    reason: Explain why the rule does not apply.\n    entries:\n", name)
+	_, _ = fmt.Fprintf(&sb, "    - dashboard: My Dashboard\n")
+	switch category {
+	case CategoryPanel:
+		_, _ = fmt.Fprintf(&sb, "      panel: My Panel\n")
+	case CategoryTarget:
+		_, _ = fmt.Fprintf(&sb, "      panel: My Panel\n      targetIdx: 0\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,44 @@
+package lint
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	rules := NewRuleSet()
+
+	t.Run("every built-in rule has embedded docs with examples", func(t *testing.T) {
+		for _, r := range rules.Rules() {
+			var buf bytes.Buffer
+			require.NoError(t, rules.Explain(&buf, r.Name()))
+			require.Contains(t, buf.String(), "```json", r.Name())
+			require.NotContains(t, buf.String(), "\nSee https://", r.Name())
+		}
+	})
+
+	t.Run("target rule", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, rules.Explain(&buf, "target-rate-interval-rule"))
+		out := buf.String()
+		require.Contains(t, out, "target-rate-interval-rule - Checks that each target uses $__rate_interval.")
+		require.Contains(t, out, "Datasources: prometheus")
+		require.Contains(t, out, "Rationale: ")
+		require.Contains(t, out, "exclusions:\n  target-rate-interval-rule:\n")
+		require.Contains(t, out, "      targetIdx: 0\n")
+	})
+
+	t.Run("dashboard rule", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, rules.Explain(&buf, "uneditable-dashboard"))
+		require.Contains(t, buf.String(), "Fixable:     yes, with --fix")
+		require.NotContains(t, buf.String(), "panel: My Panel")
+	})
+
+	t.Run("unknown rule", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.Error(t, rules.Explain(&buf, "no-such-rule"))
+	})
+}
@@ -0,0 +1,149 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// jsonFields keeps what the model does not know about the JSON object it was read from: the
+// members which are not part of the model, and which of the members that are were present, with
+// their value if it is a zero value. With it, the object is marshalled as it was read, apart
+// from the changes made to the model.
+//
+// Values built by rules rather than read have no jsonFields, and are marshalled as they are.
+type jsonFields struct {
+	unknown map[string]json.RawMessage
+	present map[string]json.RawMessage
+}
+
+// unmarshalObject unmarshals the JSON object into v, which must be a pointer to a struct type
+// without an UnmarshalJSON method, and returns what the struct does not keep.
+func unmarshalObject(buf []byte, v interface{}) (jsonFields, error) {
+	if err := json.Unmarshal(buf, v); err != nil {
+		return jsonFields{}, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &members); err != nil || members == nil {
+		// Not an object, e.g. null, which json.Unmarshal above has accepted.
+		return jsonFields{}, nil
+	}
+
+	known := modelFields(reflect.TypeOf(v).Elem())
+	f := jsonFields{present: make(map[string]json.RawMessage, len(members))}
+	for name, value := range members {
+		if _, ok := known[name]; ok {
+			f.present[name] = nil
+			if isZeroJSON(value) {
+				f.present[name] = value
+			}
+			continue
+		}
+		if f.unknown == nil {
+			f.unknown = map[string]json.RawMessage{}
+		}
+		f.unknown[name] = value
+	}
+	return f, nil
+}
+
+// marshalObject marshals v, which must be a struct type without a MarshalJSON method, as it
+// was read: members of the model which were missing are left out while they have their zero
+// value, zero values which were present are kept, even if omitempty leaves them out, and the
+// unknown members are added back. nested has what the model does not keep of the anonymous
+// structs in v, by member name.
+func marshalObject(v interface{}, f jsonFields, nested map[string]jsonFields) ([]byte, error) {
+	buf, err := json.Marshal(v)
+	if err != nil || (f.present == nil && len(nested) == 0) {
+		return buf, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &members); err != nil {
+		return nil, err
+	}
+	for name, nf := range nested {
+		value, ok := members[name]
+		if !ok || nf.present == nil {
+			continue
+		}
+		var nestedMembers map[string]json.RawMessage
+		if err := json.Unmarshal(value, &nestedMembers); err != nil || nestedMembers == nil {
+			continue
+		}
+		if members[name], err = nf.restore(nestedMembers); err != nil {
+			return nil, err
+		}
+	}
+	if f.present == nil {
+		return json.Marshal(members)
+	}
+	return f.restore(members)
+}
+
+func (f jsonFields) restore(members map[string]json.RawMessage) ([]byte, error) {
+	for name, value := range members {
+		if _, ok := f.present[name]; !ok && isZeroJSON(value) {
+			delete(members, name)
+		}
+	}
+	for name, value := range f.present {
+		if _, ok := members[name]; !ok && value != nil {
+			members[name] = value
+		}
+	}
+	for name, value := range f.unknown {
+		members[name] = value
+	}
+	return json.Marshal(members)
+}
+
+// isZeroJSON reports whether the value is what an unset field of the model marshals to: null,
+// false, 0, an empty string or array, or an object of such values.
+func isZeroJSON(value json.RawMessage) bool {
+	value = bytes.TrimSpace(value)
+	switch string(value) {
+	case "null", "false", "0", `""`, "[]", "{}":
+		return true
+	}
+	if len(value) == 0 || value[0] != '{' {
+		return false
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(value, &members); err != nil {
+		return false
+	}
+	for _, member := range members {
+		if !isZeroJSON(member) {
+			return false
+		}
+	}
+	return true
+}
+
+var modelFieldsCache sync.Map
+
+// modelFields returns the names of the JSON members of the struct type.
+func modelFields(t reflect.Type) map[string]struct{} {
+	if fields, ok := modelFieldsCache.Load(t); ok {
+		return fields.(map[string]struct{})
+	}
+	fields := map[string]struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = struct{}{}
+	}
+	modelFieldsCache.Store(t, fields)
+	return fields
+}
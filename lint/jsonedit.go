@@ -0,0 +1,523 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonSpan is the location of a JSON value in a document. Objects and arrays also locate their
+// members or elements, so values can be edited in place without reformatting the document.
+type jsonSpan struct {
+	start, end int
+	// kind is '{' for objects, '[' for arrays, and 0 for other values.
+	kind    byte
+	members []jsonMember
+	elems   []*jsonSpan
+}
+
+type jsonMember struct {
+	key              string
+	keyStart, keyEnd int
+	value            *jsonSpan
+}
+
+type jsonScanner struct {
+	buf []byte
+	pos int
+}
+
+// scanJSON locates every value of the JSON document.
+func scanJSON(buf []byte) (*jsonSpan, error) {
+	s := &jsonScanner{buf: buf}
+	v, err := s.value()
+	if err != nil {
+		return nil, err
+	}
+	s.skipSpace()
+	if s.pos != len(buf) {
+		return nil, s.errorf("unexpected data after document")
+	}
+	return v, nil
+}
+
+func (s *jsonScanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSON at offset %d: %s", s.pos, fmt.Sprintf(format, args...))
+}
+
+func (s *jsonScanner) skipSpace() {
+	for s.pos < len(s.buf) && strings.IndexByte(" \t\r\n", s.buf[s.pos]) >= 0 {
+		s.pos++
+	}
+}
+
+func (s *jsonScanner) value() (*jsonSpan, error) {
+	s.skipSpace()
+	if s.pos >= len(s.buf) {
+		return nil, s.errorf("unexpected end of document")
+	}
+	switch s.buf[s.pos] {
+	case '{':
+		return s.object()
+	case '[':
+		return s.array()
+	case '"':
+		start := s.pos
+		if err := s.str(); err != nil {
+			return nil, err
+		}
+		return &jsonSpan{start: start, end: s.pos}, nil
+	default:
+		start := s.pos
+		for s.pos < len(s.buf) && strings.IndexByte(" \t\r\n,]}", s.buf[s.pos]) < 0 {
+			s.pos++
+		}
+		if !json.Valid(s.buf[start:s.pos]) {
+			return nil, s.errorf("invalid value '%s'", s.buf[start:s.pos])
+		}
+		return &jsonSpan{start: start, end: s.pos}, nil
+	}
+}
+
+func (s *jsonScanner) str() error {
+	s.pos++ // opening quote
+	for s.pos < len(s.buf) {
+		switch s.buf[s.pos] {
+		case '\\':
+			s.pos += 2
+		case '"':
+			s.pos++
+			return nil
+		default:
+			s.pos++
+		}
+	}
+	return s.errorf("unterminated string")
+}
+
+func (s *jsonScanner) object() (*jsonSpan, error) {
+	span := &jsonSpan{start: s.pos, kind: '{'}
+	s.pos++
+	s.skipSpace()
+	if s.pos < len(s.buf) && s.buf[s.pos] == '}' {
+		s.pos++
+		span.end = s.pos
+		return span, nil
+	}
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.buf) || s.buf[s.pos] != '"' {
+			return nil, s.errorf("expected object key")
+		}
+		m := jsonMember{keyStart: s.pos}
+		if err := s.str(); err != nil {
+			return nil, err
+		}
+		m.keyEnd = s.pos
+		if err := json.Unmarshal(s.buf[m.keyStart:m.keyEnd], &m.key); err != nil {
+			return nil, s.errorf("invalid object key: %v", err)
+		}
+		s.skipSpace()
+		if s.pos >= len(s.buf) || s.buf[s.pos] != ':' {
+			return nil, s.errorf("expected ':'")
+		}
+		s.pos++
+		v, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		m.value = v
+		span.members = append(span.members, m)
+		s.skipSpace()
+		if s.pos >= len(s.buf) {
+			return nil, s.errorf("unterminated object")
+		}
+		if s.buf[s.pos] == '}' {
+			s.pos++
+			span.end = s.pos
+			return span, nil
+		}
+		if s.buf[s.pos] != ',' {
+			return nil, s.errorf("expected ',' or '}'")
+		}
+		s.pos++
+	}
+}
+
+func (s *jsonScanner) array() (*jsonSpan, error) {
+	span := &jsonSpan{start: s.pos, kind: '['}
+	s.pos++
+	s.skipSpace()
+	if s.pos < len(s.buf) && s.buf[s.pos] == ']' {
+		s.pos++
+		span.end = s.pos
+		return span, nil
+	}
+	for {
+		v, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		span.elems = append(span.elems, v)
+		s.skipSpace()
+		if s.pos >= len(s.buf) {
+			return nil, s.errorf("unterminated array")
+		}
+		if s.buf[s.pos] == ']' {
+			s.pos++
+			span.end = s.pos
+			return span, nil
+		}
+		if s.buf[s.pos] != ',' {
+			return nil, s.errorf("expected ',' or ']'")
+		}
+		s.pos++
+	}
+}
+
+func (span *jsonSpan) member(key string) (int, bool) {
+	for i, m := range span.members {
+		if m.key == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// locate returns the span of the value the pointer tokens refer to.
+func (span *jsonSpan) locate(tokens []string) (*jsonSpan, error) {
+	for _, t := range tokens {
+		switch span.kind {
+		case '{':
+			i, ok := span.member(t)
+			if !ok {
+				return nil, fmt.Errorf("member '%s' not found", t)
+			}
+			span = span.members[i].value
+		case '[':
+			i, err := arrayIndex(t, len(span.elems), false)
+			if err != nil {
+				return nil, err
+			}
+			span = span.elems[i]
+		default:
+			return nil, fmt.Errorf("cannot traverse into a scalar with '%s'", t)
+		}
+	}
+	return span, nil
+}
+
+// jsonEditor applies JSON Patch operations to the bytes of a JSON document. Only the values the
+// operations change are rewritten, following the indentation of their surroundings, so the
+// formatting, key order and any other content of the document are kept.
+type jsonEditor struct {
+	buf []byte
+	// unit is the indentation of one nesting level in the document, empty if it is compact.
+	unit string
+}
+
+func newJSONEditor(buf []byte) *jsonEditor {
+	return &jsonEditor{buf: buf, unit: detectIndentUnit(buf)}
+}
+
+// patchBytes applies the operations to the JSON document, in order, and returns the patched
+// document.
+func patchBytes(buf []byte, ops []PatchOperation) ([]byte, error) {
+	e := newJSONEditor(buf)
+	for _, op := range ops {
+		if err := e.apply(op); err != nil {
+			return nil, fmt.Errorf("json patch %s '%s': %w", op.Op, op.Path, err)
+		}
+	}
+	return e.buf, nil
+}
+
+// detectIndentUnit returns the indentation of the first indented line of the document.
+func detectIndentUnit(buf []byte) string {
+	for _, line := range bytes.Split(buf, []byte("\n"))[1:] {
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(trimmed) > 0 && len(trimmed) < len(line) {
+			return string(line[:len(line)-len(trimmed)])
+		}
+	}
+	return ""
+}
+
+// lineIndent returns the whitespace at the start of the line containing pos.
+func (e *jsonEditor) lineIndent(pos int) string {
+	start := bytes.LastIndexByte(e.buf[:pos], '\n') + 1
+	end := start
+	for end < len(e.buf) && (e.buf[end] == ' ' || e.buf[end] == '\t') {
+		end++
+	}
+	return string(e.buf[start:end])
+}
+
+// multiline reports whether the first member or element of a container is on a new line.
+func (e *jsonEditor) multiline(span *jsonSpan, first int) bool {
+	return bytes.IndexByte(e.buf[span.start:first], '\n') >= 0
+}
+
+// render marshals the value for insertion at a position with the given indentation.
+func (e *jsonEditor) render(value interface{}, indent string, pretty bool) (string, error) {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	if pretty && e.unit != "" {
+		enc.SetIndent(indent, e.unit)
+	}
+	if err := enc.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func (e *jsonEditor) splice(start, end int, text string) {
+	buf := make([]byte, 0, len(e.buf)-(end-start)+len(text))
+	buf = append(buf, e.buf[:start]...)
+	buf = append(buf, text...)
+	buf = append(buf, e.buf[end:]...)
+	e.buf = buf
+}
+
+func (e *jsonEditor) root() (*jsonSpan, error) {
+	return scanJSON(e.buf)
+}
+
+func (e *jsonEditor) apply(op PatchOperation) error {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add":
+		return e.add(tokens, op.Value)
+	case "remove":
+		return e.remove(tokens)
+	case "replace":
+		return e.replace(tokens, op.Value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return err
+		}
+		root, err := e.root()
+		if err != nil {
+			return err
+		}
+		span, err := root.locate(from)
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if err := json.Unmarshal(e.buf[span.start:span.end], &value); err != nil {
+			return err
+		}
+		if op.Op == "move" {
+			if err := e.remove(from); err != nil {
+				return err
+			}
+		}
+		return e.add(tokens, value)
+	case "test":
+		root, err := e.root()
+		if err != nil {
+			return err
+		}
+		span, err := root.locate(tokens)
+		if err != nil {
+			return err
+		}
+		var actual interface{}
+		if err := json.Unmarshal(e.buf[span.start:span.end], &actual); err != nil {
+			return err
+		}
+		expected, err := normalizeJSON(op.Value)
+		if err != nil {
+			return err
+		}
+		if !jsonEqual(actual, expected) {
+			return fmt.Errorf("value is %v, not %v", actual, expected)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown operation")
+	}
+}
+
+func (e *jsonEditor) replace(tokens []string, value interface{}) error {
+	root, err := e.root()
+	if err != nil {
+		return err
+	}
+	span, err := root.locate(tokens)
+	if err != nil {
+		return err
+	}
+	text, err := e.render(value, e.lineIndent(span.start), true)
+	if err != nil {
+		return err
+	}
+	e.splice(span.start, span.end, text)
+	return nil
+}
+
+func (e *jsonEditor) add(tokens []string, value interface{}) error {
+	if len(tokens) == 0 {
+		return e.replace(tokens, value)
+	}
+	root, err := e.root()
+	if err != nil {
+		return err
+	}
+	parent, err := root.locate(tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+
+	starts, ends := parent.items()
+	switch parent.kind {
+	case '{':
+		if _, ok := parent.member(last); ok {
+			return e.replace(tokens, value)
+		}
+		key, err := json.Marshal(last)
+		if err != nil {
+			return err
+		}
+		if len(parent.members) == 0 {
+			return e.fillEmpty(parent, string(key)+": ", value)
+		}
+		prev := parent.members[len(parent.members)-1]
+		text, err := e.render(value, e.lineIndent(prev.keyStart), e.multiline(parent, starts[0]))
+		if err != nil {
+			return err
+		}
+		// Reuse the separator between the key and value of the previous member.
+		sep := string(e.buf[prev.keyEnd:prev.value.start])
+		e.splice(prev.value.end, prev.value.end, ","+e.itemSpace(parent, starts, ends)+string(key)+sep+text)
+		return nil
+	case '[':
+		i, err := arrayIndex(last, len(parent.elems), true)
+		if err != nil {
+			return err
+		}
+		if len(parent.elems) == 0 {
+			return e.fillEmpty(parent, "", value)
+		}
+		pretty := e.multiline(parent, starts[0])
+		if i == len(parent.elems) {
+			text, err := e.render(value, e.lineIndent(starts[i-1]), pretty)
+			if err != nil {
+				return err
+			}
+			e.splice(ends[i-1], ends[i-1], ","+e.itemSpace(parent, starts, ends)+text)
+			return nil
+		}
+		// The new element takes the place of the next one, which moves after a separator.
+		text, err := e.render(value, e.lineIndent(starts[i]), pretty)
+		if err != nil {
+			return err
+		}
+		e.splice(starts[i], starts[i], text+","+e.itemSpace(parent, starts, ends))
+		return nil
+	default:
+		return fmt.Errorf("cannot add to a scalar")
+	}
+}
+
+// items returns the offsets of the members, including their keys, or elements of a container.
+func (span *jsonSpan) items() (starts, ends []int) {
+	for _, m := range span.members {
+		starts, ends = append(starts, m.keyStart), append(ends, m.value.end)
+	}
+	for _, v := range span.elems {
+		starts, ends = append(starts, v.start), append(ends, v.end)
+	}
+	return starts, ends
+}
+
+// itemSpace returns the whitespace which separates the items of a non-empty container after
+// their comma.
+func (e *jsonEditor) itemSpace(span *jsonSpan, starts, ends []int) string {
+	if len(starts) > 1 {
+		comma := ends[0] + bytes.IndexByte(e.buf[ends[0]:starts[1]], ',') + 1
+		return string(e.buf[comma:starts[1]])
+	}
+	if e.multiline(span, starts[0]) {
+		return "\n" + e.lineIndent(starts[0])
+	}
+	return string(e.buf[span.start+1 : starts[0]])
+}
+
+// fillEmpty writes the first member or element of an empty object or array.
+func (e *jsonEditor) fillEmpty(span *jsonSpan, prefix string, value interface{}) error {
+	open, end := string(e.buf[span.start]), string(e.buf[span.end-1])
+	if e.unit == "" {
+		text, err := e.render(value, "", false)
+		if err != nil {
+			return err
+		}
+		e.splice(span.start, span.end, open+prefix+text+end)
+		return nil
+	}
+	indent := e.lineIndent(span.start)
+	text, err := e.render(value, indent+e.unit, true)
+	if err != nil {
+		return err
+	}
+	e.splice(span.start, span.end, open+"\n"+indent+e.unit+prefix+text+"\n"+indent+end)
+	return nil
+}
+
+func (e *jsonEditor) remove(tokens []string) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("cannot remove the document root")
+	}
+	root, err := e.root()
+	if err != nil {
+		return err
+	}
+	parent, err := root.locate(tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+
+	var i int
+	switch parent.kind {
+	case '{':
+		var ok bool
+		if i, ok = parent.member(last); !ok {
+			return fmt.Errorf("member '%s' not found", last)
+		}
+	case '[':
+		if i, err = arrayIndex(last, len(parent.elems), false); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot remove from a scalar")
+	}
+
+	starts, ends := parent.items()
+	switch {
+	case len(starts) == 1:
+		e.splice(parent.start+1, parent.end-1, "")
+	case i < len(starts)-1:
+		// Remove up to the next item, which takes the place of the removed one.
+		e.splice(starts[i], starts[i+1], "")
+	default:
+		// Remove the last item and the separator after the previous one.
+		e.splice(ends[i-1], ends[i], "")
+	}
+	return nil
+}
+
+// jsonEqual compares two generic JSON values.
+func jsonEqual(a, b interface{}) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ab, bb)
+}
@@ -0,0 +1,183 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchBytes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		doc      string
+		ops      []PatchOperation
+		expected string
+		err      string
+	}{
+		{
+			name:     "replace scalar",
+			doc:      "{\n\t\"b\": 1,\n\t\"a\": \"x\"\n}",
+			ops:      []PatchOperation{{Op: "replace", Path: "/a", Value: "<y>"}},
+			expected: "{\n\t\"b\": 1,\n\t\"a\": \"<y>\"\n}",
+		},
+		{
+			name:     "replace object follows indentation",
+			doc:      "{\n  \"a\": {\n    \"b\": 1\n  },\n  \"c\": 2\n}",
+			ops:      []PatchOperation{{Op: "replace", Path: "/a", Value: map[string]interface{}{"d": []int{1}}}},
+			expected: "{\n  \"a\": {\n    \"d\": [\n      1\n    ]\n  },\n  \"c\": 2\n}",
+		},
+		{
+			name:     "add member to pretty object",
+			doc:      "{\n  \"a\": 1\n}",
+			ops:      []PatchOperation{{Op: "add", Path: "/b", Value: map[string]interface{}{"c": true}}},
+			expected: "{\n  \"a\": 1,\n  \"b\": {\n    \"c\": true\n  }\n}",
+		},
+		{
+			name:     "add member to compact object",
+			doc:      `{"a":1,"b":{"c":2}}`,
+			ops:      []PatchOperation{{Op: "add", Path: "/b/d", Value: []int{3}}},
+			expected: `{"a":1,"b":{"c":2,"d":[3]}}`,
+		},
+		{
+			name:     "add existing member replaces it",
+			doc:      `{"a": 1, "b": 2}`,
+			ops:      []PatchOperation{{Op: "add", Path: "/a", Value: 3}},
+			expected: `{"a": 3, "b": 2}`,
+		},
+		{
+			name:     "add to empty containers",
+			doc:      "{\n  \"a\": {},\n  \"b\": []\n}",
+			ops:      []PatchOperation{{Op: "add", Path: "/a/x", Value: 1}, {Op: "add", Path: "/b/0", Value: 2}},
+			expected: "{\n  \"a\": {\n    \"x\": 1\n  },\n  \"b\": [\n    2\n  ]\n}",
+		},
+		{
+			name: "add array elements",
+			doc:  "[\n  1,\n  3\n]",
+			ops: []PatchOperation{
+				{Op: "add", Path: "/0", Value: 0},
+				{Op: "add", Path: "/2", Value: 2},
+				{Op: "add", Path: "/-", Value: 4},
+			},
+			expected: "[\n  0,\n  1,\n  2,\n  3,\n  4\n]",
+		},
+		{
+			name:     "add to compact array",
+			doc:      `[1, 3]`,
+			ops:      []PatchOperation{{Op: "add", Path: "/1", Value: 2}, {Op: "add", Path: "/-", Value: 4}},
+			expected: `[1, 2, 3, 4]`,
+		},
+		{
+			name: "remove members",
+			doc:  "{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3\n}",
+			ops: []PatchOperation{
+				{Op: "remove", Path: "/a"},
+				{Op: "remove", Path: "/c"},
+			},
+			expected: "{\n  \"b\": 2\n}",
+		},
+		{
+			name:     "remove only element",
+			doc:      `{"a": [ 1 ]}`,
+			ops:      []PatchOperation{{Op: "remove", Path: "/a/0"}},
+			expected: `{"a": []}`,
+		},
+		{
+			name:     "move and copy",
+			doc:      `{"a": {"b": 1}, "c": [2]}`,
+			ops:      []PatchOperation{{Op: "move", From: "/a/b", Path: "/d"}, {Op: "copy", From: "/c", Path: "/e"}},
+			expected: `{"a": {}, "c": [2], "d": 1, "e": [2]}`,
+		},
+		{
+			name:     "test",
+			doc:      `{"a": {"b": [1]}}`,
+			ops:      []PatchOperation{{Op: "test", Path: "/a", Value: map[string]interface{}{"b": []int{1}}}},
+			expected: `{"a": {"b": [1]}}`,
+		},
+		{
+			name: "escaped keys and strings are kept",
+			doc:  `{"a/b": "\"x\"", "c~d": 1}`,
+			ops: []PatchOperation{
+				{Op: "replace", Path: "/c~0d", Value: "<&>"},
+			},
+			expected: `{"a/b": "\"x\"", "c~d": "<&>"}`,
+		},
+		{
+			name: "failed test",
+			doc:  `{"a": 1}`,
+			ops:  []PatchOperation{{Op: "test", Path: "/a", Value: 2}},
+			err:  "json patch test '/a': value is 1, not 2",
+		},
+		{
+			name: "missing member",
+			doc:  `{"a": 1}`,
+			ops:  []PatchOperation{{Op: "remove", Path: "/b"}},
+			err:  "json patch remove '/b': member 'b' not found",
+		},
+		{
+			name: "invalid document",
+			doc:  `{"a": 1`,
+			ops:  []PatchOperation{{Op: "remove", Path: "/a"}},
+			err:  "invalid JSON at offset 7",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := patchBytes([]byte(tc.doc), tc.ops)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		a, b     interface{}
+		expected []PatchOperation
+	}{
+		{
+			name: "equal",
+			a:    map[string]interface{}{"a": []interface{}{1.0}},
+			b:    map[string]interface{}{"a": []interface{}{1.0}},
+		},
+		{
+			name: "members",
+			a:    map[string]interface{}{"a": 1.0, "b": 2.0, "c/d": map[string]interface{}{"e": true}},
+			b:    map[string]interface{}{"a": 1.0, "c/d": map[string]interface{}{"e": false}, "f": "x"},
+			expected: []PatchOperation{
+				{Op: "remove", Path: "/b"},
+				{Op: "replace", Path: "/c~1d/e", Value: false},
+				{Op: "add", Path: "/f", Value: "x"},
+			},
+		},
+		{
+			name: "array elements",
+			a:    []interface{}{1.0, 2.0, 3.0, 4.0, 5.0},
+			b:    []interface{}{1.0, 6.0, 5.0},
+			expected: []PatchOperation{
+				{Op: "replace", Path: "/1", Value: 6.0},
+				{Op: "remove", Path: "/3"},
+				{Op: "remove", Path: "/2"},
+			},
+		},
+		{
+			name:     "inserted array element",
+			a:        []interface{}{1.0, 3.0},
+			b:        []interface{}{1.0, 2.0, 3.0},
+			expected: []PatchOperation{{Op: "add", Path: "/1", Value: 2.0}},
+		},
+		{
+			name:     "type change",
+			a:        map[string]interface{}{"a": "x"},
+			b:        map[string]interface{}{"a": []interface{}{"x"}},
+			expected: []PatchOperation{{Op: "replace", Path: "/a", Value: []interface{}{"x"}}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, diffJSON(nil, tc.a, tc.b))
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchOperation is a single JSON Patch (RFC 6902) operation.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer '%s'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses a reference token as an index into an array of length n. When appending
+// is true the index may also be n, or "-".
+func arrayIndex(token string, n int, appending bool) (int, error) {
+	if appending && token == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > n || (i == n && !appending) {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	return i, nil
+}
+
+// patchGet returns the value referenced by the pointer tokens.
+func patchGet(doc interface{}, tokens []string) (interface{}, error) {
+	for _, t := range tokens {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			child, ok := v[t]
+			if !ok {
+				return nil, fmt.Errorf("member '%s' not found", t)
+			}
+			doc = child
+		case []interface{}:
+			i, err := arrayIndex(t, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("cannot traverse into %T with '%s'", doc, t)
+		}
+	}
+	return doc, nil
+}
+
+// patchUpdate calls fn with the container of the last pointer token and stores the container
+// it returns. This is needed because growing or shrinking an array creates a new slice.
+func patchUpdate(doc interface{}, tokens []string, fn func(container interface{}, last string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("cannot update the document root")
+	}
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+	parent, err := patchGet(doc, tokens[:len(tokens)-2])
+	if err != nil {
+		return nil, err
+	}
+	key := tokens[len(tokens)-2]
+	container, err := patchGet(parent, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fn(container, tokens[len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[key] = updated
+	case []interface{}:
+		i, _ := arrayIndex(key, len(p), false)
+		p[i] = updated
+	}
+	return doc, nil
+}
+
+func patchAdd(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return patchUpdate(doc, tokens, func(container interface{}, last string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[last] = value
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(last, len(c), true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("cannot add to %T", container)
+		}
+	})
+}
+
+func patchRemove(doc interface{}, tokens []string) (interface{}, error) {
+	return patchUpdate(doc, tokens, func(container interface{}, last string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[last]; !ok {
+				return nil, fmt.Errorf("member '%s' not found", last)
+			}
+			delete(c, last)
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(last, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove from %T", container)
+		}
+	})
+}
+
+// normalizeJSON converts a value into its generic JSON representation (maps, slices, float64, ...).
+func normalizeJSON(v interface{}) (interface{}, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(buf, &out)
+	return out, err
+}
+
+// applyPatch applies the operations to a generic JSON document, in order, and returns the
+// patched document. The document is modified in place.
+func applyPatch(doc interface{}, ops []PatchOperation) (interface{}, error) {
+	for _, op := range ops {
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		value, err := normalizeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			doc, err = patchAdd(doc, tokens, value)
+		case "remove":
+			doc, err = patchRemove(doc, tokens)
+		case "replace":
+			if _, err = patchGet(doc, tokens); err == nil {
+				if len(tokens) == 0 {
+					doc = value
+				} else if doc, err = patchRemove(doc, tokens); err == nil {
+					doc, err = patchAdd(doc, tokens, value)
+				}
+			}
+		case "move", "copy":
+			var from []string
+			if from, err = parsePointer(op.From); err != nil {
+				return nil, err
+			}
+			if value, err = patchGet(doc, from); err != nil {
+				break
+			}
+			if value, err = normalizeJSON(value); err != nil {
+				break
+			}
+			if op.Op == "move" {
+				if doc, err = patchRemove(doc, from); err != nil {
+					break
+				}
+			}
+			doc, err = patchAdd(doc, tokens, value)
+		case "test":
+			var actual interface{}
+			if actual, err = patchGet(doc, tokens); err == nil && !reflect.DeepEqual(actual, value) {
+				err = fmt.Errorf("value is %v, not %v", actual, value)
+			}
+		default:
+			err = fmt.Errorf("unknown operation")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("json patch %s '%s': %w", op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// formatPointer joins reference tokens into a JSON Pointer, escaping them.
+func formatPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString("/")
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(t, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+// diffJSON returns the JSON Patch operations which turn the generic JSON value a into b. Objects
+// are compared member by member, and arrays element by element after skipping their common
+// prefix and suffix, so the operations only touch the values which changed.
+func diffJSON(tokens []string, a, b interface{}) []PatchOperation {
+	replace := []PatchOperation{{Op: "replace", Path: formatPointer(tokens), Value: b}}
+	child := func(t string) []string {
+		return append(append([]string{}, tokens...), t)
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return replace
+		}
+		var ops []PatchOperation
+		for _, k := range sortedKeys(av) {
+			if _, ok := bv[k]; !ok {
+				ops = append(ops, PatchOperation{Op: "remove", Path: formatPointer(child(k))})
+			}
+		}
+		for _, k := range sortedKeys(bv) {
+			if old, ok := av[k]; ok {
+				ops = append(ops, diffJSON(child(k), old, bv[k])...)
+			} else {
+				ops = append(ops, PatchOperation{Op: "add", Path: formatPointer(child(k)), Value: bv[k]})
+			}
+		}
+		return ops
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			return replace
+		}
+		prefix := 0
+		for prefix < len(av) && prefix < len(bv) && reflect.DeepEqual(av[prefix], bv[prefix]) {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(av)-prefix && suffix < len(bv)-prefix &&
+			reflect.DeepEqual(av[len(av)-1-suffix], bv[len(bv)-1-suffix]) {
+			suffix++
+		}
+		oldMid, newMid := av[prefix:len(av)-suffix], bv[prefix:len(bv)-suffix]
+
+		var ops []PatchOperation
+		common := len(oldMid)
+		if len(newMid) < common {
+			common = len(newMid)
+		}
+		for i := 0; i < common; i++ {
+			ops = append(ops, diffJSON(child(strconv.Itoa(prefix+i)), oldMid[i], newMid[i])...)
+		}
+		// Remove from the end so the indices of the remaining elements stay valid.
+		for i := len(oldMid) - 1; i >= common; i-- {
+			ops = append(ops, PatchOperation{Op: "remove", Path: formatPointer(child(strconv.Itoa(prefix + i)))})
+		}
+		for i := common; i < len(newMid); i++ {
+			ops = append(ops, PatchOperation{Op: "add", Path: formatPointer(child(strconv.Itoa(prefix + i))), Value: newMid[i]})
+		}
+		return ops
+	default:
+		if reflect.DeepEqual(a, b) {
+			return nil
+		}
+		return replace
+	}
+}
@@ -0,0 +1,87 @@
+package lint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		doc      string
+		ops      []PatchOperation
+		expected string
+		err      string
+	}{
+		{
+			name:     "add member and array element",
+			doc:      `{"a": [1, 3]}`,
+			ops:      []PatchOperation{{Op: "add", Path: "/b", Value: "x"}, {Op: "add", Path: "/a/1", Value: 2}, {Op: "add", Path: "/a/-", Value: 4}},
+			expected: `{"a": [1, 2, 3, 4], "b": "x"}`,
+		},
+		{
+			name:     "remove",
+			doc:      `{"a": [1, 2], "b": {"c": true}}`,
+			ops:      []PatchOperation{{Op: "remove", Path: "/a/0"}, {Op: "remove", Path: "/b/c"}},
+			expected: `{"a": [2], "b": {}}`,
+		},
+		{
+			name:     "replace nested",
+			doc:      `{"panels": [{"title": "a"}, {"title": "b"}]}`,
+			ops:      []PatchOperation{{Op: "replace", Path: "/panels/1/title", Value: "c"}},
+			expected: `{"panels": [{"title": "a"}, {"title": "c"}]}`,
+		},
+		{
+			name:     "escaped pointer",
+			doc:      `{"a/b": {"m~n": 1}}`,
+			ops:      []PatchOperation{{Op: "replace", Path: "/a~1b/m~0n", Value: 2}},
+			expected: `{"a/b": {"m~n": 2}}`,
+		},
+		{
+			name:     "move and copy",
+			doc:      `{"a": {"x": 1}, "b": []}`,
+			ops:      []PatchOperation{{Op: "copy", From: "/a/x", Path: "/b/0"}, {Op: "move", From: "/a", Path: "/c"}},
+			expected: `{"b": [1], "c": {"x": 1}}`,
+		},
+		{
+			name:     "test",
+			doc:      `{"a": {"x": 1}}`,
+			ops:      []PatchOperation{{Op: "test", Path: "/a", Value: map[string]int{"x": 1}}},
+			expected: `{"a": {"x": 1}}`,
+		},
+		{
+			name: "failed test",
+			doc:  `{"a": 1}`,
+			ops:  []PatchOperation{{Op: "test", Path: "/a", Value: 2}},
+			err:  "json patch test '/a': value is 1, not 2",
+		},
+		{
+			name: "replace missing member",
+			doc:  `{}`,
+			ops:  []PatchOperation{{Op: "replace", Path: "/a", Value: 2}},
+			err:  "json patch replace '/a': member 'a' not found",
+		},
+		{
+			name: "index out of range",
+			doc:  `{"a": []}`,
+			ops:  []PatchOperation{{Op: "add", Path: "/a/1", Value: 2}},
+			err:  "json patch add '/a/1': invalid array index '1'",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var doc interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.doc), &doc))
+			actual, err := applyPatch(doc, tc.ops)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			buf, err := json.Marshal(actual)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expected, string(buf))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LibraryPanel is a panel shared by dashboards, as exported from Grafana. Dashboards only have
+// a reference to it, which is replaced by its model before linting.
+type LibraryPanel struct {
+	UID   string `json:"uid"`
+	Name  string `json:"name"`
+	Model Panel  `json:"model"`
+
+	// Source is where the library panel was read from, e.g. its file name, if known.
+	Source string `json:"-"`
+
+	// model is the pointer of the model in the JSON the library panel was parsed from.
+	model []string
+}
+
+// LibraryPanels are library panels by uid.
+type LibraryPanels map[string]*LibraryPanel
+
+// ParseLibraryPanel parses an exported library panel, either the library element itself or the
+// response of the library elements API, which wraps it in a result.
+func ParseLibraryPanel(buf []byte) (*LibraryPanel, error) {
+	var wrapped struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(buf, &wrapped); err != nil {
+		return nil, err
+	}
+	model := []string{"model"}
+	if wrapped.Result != nil {
+		buf = wrapped.Result
+		model = []string{"result", "model"}
+	}
+
+	var lp struct {
+		LibraryPanel
+		Model json.RawMessage `json:"model"`
+	}
+	if err := json.Unmarshal(buf, &lp); err != nil {
+		return nil, err
+	}
+	if lp.UID == "" || lp.Model == nil {
+		return nil, fmt.Errorf("not a library panel, it has no uid or model")
+	}
+	if err := json.Unmarshal(lp.Model, &lp.LibraryPanel.Model); err != nil {
+		return nil, fmt.Errorf("library panel '%s' model: %w", lp.UID, err)
+	}
+	lp.LibraryPanel.model = model
+	return &lp.LibraryPanel, nil
+}
+
+// translate turns operations on the dashboard a library panel is linted in into operations on
+// the model in the library panel JSON, in place. It returns the fixed model in the shape of that
+// JSON, given the fixed dashboard.
+func (lp *LibraryPanel) translate(ops []PatchOperation, fixed map[string]interface{}) (interface{}, error) {
+	const panel = "/panels/0"
+	model := formatPointer(lp.model)
+	for i, op := range ops {
+		if op.Path != panel && !strings.HasPrefix(op.Path, panel+"/") {
+			return nil, fmt.Errorf("fix of '%s' cannot be written to a library panel", op.Path)
+		}
+		ops[i].Path = model + strings.TrimPrefix(op.Path, panel)
+	}
+
+	panels, _ := fixed["panels"].([]interface{})
+	if len(panels) != 1 {
+		return nil, fmt.Errorf("fix of the library panel must keep its panel")
+	}
+	doc := panels[0]
+	for i := len(lp.model) - 1; i >= 0; i-- {
+		doc = map[string]interface{}{lp.model[i]: doc}
+	}
+	return doc, nil
+}
+
+// LoadLibraryPanels reads the library panels exported to the JSON files in the directory.
+func LoadLibraryPanels(dir string) (LibraryPanels, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if files == nil {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+
+	lib := LibraryPanels{}
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		lp, err := ParseLibraryPanel(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse library panel %s: %w", file, err)
+		}
+		if other, ok := lib[lp.UID]; ok {
+			return nil, fmt.Errorf("library panel uid '%s' of %s is also used by %s", lp.UID, file, other.Source)
+		}
+		lp.Source = file
+		lib[lp.UID] = lp
+	}
+	return lib, nil
+}
+
+// ResolveLibraryPanels replaces the library panel references of the dashboard with the models
+// of the library panels. The panels keep their id, position and reference. They are linted like
+// the other panels, and their results are attributed to the library panel as well, but they are
+// not fixed, as they are not part of the dashboard.
+//
+// References which are not found in the library panels are left as they are, and reported by the
+// library-panel-rule. If they are only a reference, there is nothing to lint.
+func (d *Dashboard) ResolveLibraryPanels(lib LibraryPanels) {
+	d.libraryPanels = lib
+	for i := range d.Rows {
+		resolveLibraryPanels(d.Rows[i].Panels, lib)
+	}
+	resolveLibraryPanels(d.Panels, lib)
+}
+
+func resolveLibraryPanels(panels []Panel, lib LibraryPanels) {
+	for i, p := range panels {
+		if p.LibraryPanel == nil {
+			resolveLibraryPanels(p.Panels, lib)
+			continue
+		}
+		lp, ok := lib[p.LibraryPanel.UID]
+		if !ok {
+			continue
+		}
+		resolved := lp.Model
+		resolved.Id, resolved.GridPos, resolved.LibraryPanel = p.Id, p.GridPos, p.LibraryPanel
+		if resolved.Title == "" {
+			resolved.Title = p.Title
+		}
+		resolved.library = lp
+		panels[i] = resolved
+	}
+}
+
+// isLibraryPanelReference reports whether the panel is a library panel reference which has not
+// been resolved, and has no model of its own.
+func isLibraryPanelReference(p Panel) bool {
+	return p.LibraryPanel != nil && p.library == nil && p.Type == "" && len(p.Targets) == 0
+}
+
+// libraryPanelTemplates returns the datasource variables the panel uses, as far as they are
+// known from the panel, to lint it as if it were in a dashboard which has them.
+func libraryPanelTemplates(p Panel) []Template {
+	var templates []Template
+	seen := map[string]bool{}
+	add := func(raw interface{}) {
+		ds, err := GetDataSource(raw)
+		if err != nil || ds.Type == "" || !strings.HasPrefix(ds.UID, "$") {
+			return
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(ds.UID, "$"), "{"), "}")
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		templates = append(templates, Template{Name: name, Type: "datasource", Query: ds.Type})
+	}
+	add(p.Datasource)
+	for _, t := range p.Targets {
+		add(t.Datasource)
+	}
+	return templates
+}
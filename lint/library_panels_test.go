@@ -0,0 +1,246 @@
+package lint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/dashboard-linter/lint"
+)
+
+const libraryPanel = `{
+	"uid": "cpu",
+	"name": "CPU",
+	"kind": 1,
+	"model": {
+		"type": "timeseries",
+		"title": "CPU usage",
+		"datasource": { "type": "prometheus", "uid": "$datasource" },
+		"targets": [ { "refId": "A", "expr": "sum(rate(cpu_seconds_total[5m]))" } ]
+	}
+}`
+
+const libraryPanelDashboard = `{
+	"title": "test",
+	"templating": { "list": [ { "name": "datasource", "type": "datasource", "query": "prometheus" } ] },
+	"panels": [
+		{ "id": 1, "gridPos": { "h": 8, "w": 12, "x": 0, "y": 0 }, "libraryPanel": { "uid": "cpu", "name": "CPU" } },
+		{
+			"id": 2,
+			"type": "row",
+			"title": "row",
+			"panels": [ { "id": 3, "title": "CPU again", "libraryPanel": { "uid": "cpu", "name": "CPU" } } ]
+		}
+	]
+}`
+
+func writeLibraryPanels(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	return dir
+}
+
+func TestLoadLibraryPanels(t *testing.T) {
+	t.Run("exported and api response", func(t *testing.T) {
+		dir := writeLibraryPanels(t, map[string]string{
+			"cpu.json":    libraryPanel,
+			"memory.json": `{ "result": { "uid": "memory", "name": "Memory", "model": { "type": "stat" } } }`,
+			"README.md":   "not a library panel",
+		})
+		lib, err := lint.LoadLibraryPanels(dir)
+		require.NoError(t, err)
+		require.Len(t, lib, 2)
+		require.Equal(t, "CPU", lib["cpu"].Name)
+		require.Equal(t, "CPU usage", lib["cpu"].Model.Title)
+		require.Equal(t, filepath.Join(dir, "cpu.json"), lib["cpu"].Source)
+		require.Equal(t, "stat", lib["memory"].Model.Type)
+	})
+
+	t.Run("duplicate uid", func(t *testing.T) {
+		dir := writeLibraryPanels(t, map[string]string{"a.json": libraryPanel, "b.json": libraryPanel})
+		_, err := lint.LoadLibraryPanels(dir)
+		require.ErrorContains(t, err, "library panel uid 'cpu'")
+	})
+
+	t.Run("not a library panel", func(t *testing.T) {
+		dir := writeLibraryPanels(t, map[string]string{"dashboard.json": libraryPanelDashboard})
+		_, err := lint.LoadLibraryPanels(dir)
+		require.ErrorContains(t, err, "not a library panel")
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := lint.LoadLibraryPanels(filepath.Join(t.TempDir(), "missing"))
+		require.Error(t, err)
+	})
+}
+
+func TestLinterLibraryPanels(t *testing.T) {
+	dir := writeLibraryPanels(t, map[string]string{"cpu.json": libraryPanel})
+	lib, err := lint.LoadLibraryPanels(dir)
+	require.NoError(t, err)
+
+	config := lint.NewConfigurationFile()
+	config.Autofix = true
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewTargetRateIntervalRule()),
+		lint.WithConfig(config),
+		lint.WithLibraryPanels(lib),
+	)
+	require.NoError(t, err)
+
+	res, err := linter.Lint([]byte(libraryPanelDashboard))
+	require.NoError(t, err)
+	// The library panel is not part of the dashboard, so it is not fixed there.
+	require.Nil(t, res.Fixed)
+
+	results := res.Results.Results()
+	require.Len(t, results, 2)
+	// The title of the library panel is used, like Grafana does.
+	for i, id := range []int{1, 3} {
+		rc := results[i]
+		require.Equal(t, id, rc.Panel.Id)
+		require.Equal(t, "cpu", rc.LibraryPanel.UID)
+		require.Len(t, rc.Result.Results, 1)
+		require.Equal(t, lint.Error, rc.Result.Results[0].Severity)
+		require.Nil(t, rc.Result.Results[0].Fix)
+		require.Contains(t, rc.Result.Results[0].Message, "Dashboard 'test', panel 'CPU usage' (library panel 'CPU'), target idx '0'")
+	}
+	require.Equal(t, 12, results[0].Panel.GridPos.W)
+
+	var out bytes.Buffer
+	require.NoError(t, lint.JSONReporter{}.Report(&out, res.Results))
+	var report struct {
+		Results []struct {
+			LibraryPanel       string `json:"libraryPanel"`
+			LibraryPanelSource string `json:"libraryPanelSource"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Equal(t, "cpu", report.Results[0].LibraryPanel)
+	require.Equal(t, filepath.Join(dir, "cpu.json"), report.Results[0].LibraryPanelSource)
+
+	t.Run("missing library panel", func(t *testing.T) {
+		linter, err := lint.NewLinter(
+			lint.WithRules(lint.NewLibraryPanelRule(), lint.NewPanelTitleDescriptionRule()),
+			lint.WithLibraryPanels(lint.LibraryPanels{}),
+		)
+		require.NoError(t, err)
+		res, err := linter.Lint([]byte(libraryPanelDashboard))
+		require.NoError(t, err)
+
+		results := res.Results.ByRule()
+		require.Len(t, results["library-panel-rule"], 1)
+		var messages []string
+		for _, r := range results["library-panel-rule"][0].Result.Results {
+			messages = append(messages, r.Message)
+		}
+		require.Equal(t, []string{
+			"Dashboard 'test' panel 'CPU' uses library panel 'cpu', which is not found",
+			"Dashboard 'test' panel 'CPU again' uses library panel 'cpu', which is not found",
+		}, messages)
+		// The other panels are still linted.
+		require.Len(t, results["panel-title-description-rule"], 1)
+		require.Equal(t, "row", results["panel-title-description-rule"][0].Panel.Title)
+	})
+
+	t.Run("inline model", func(t *testing.T) {
+		linter, err := lint.NewLinter(lint.WithRules(lint.NewLibraryPanelRule(), lint.NewPanelTitleDescriptionRule()))
+		require.NoError(t, err)
+		res, err := linter.Lint([]byte(`{
+			"title": "test",
+			"panels": [ { "id": 1, "type": "timeseries", "title": "CPU", "libraryPanel": { "uid": "cpu", "name": "CPU" } } ]
+		}`))
+		require.NoError(t, err)
+		results := res.Results.ByRule()
+		// Without library panels, the model saved along with the reference is linted.
+		require.Len(t, results["panel-title-description-rule"], 1)
+		require.Equal(t, "CPU", results["panel-title-description-rule"][0].Panel.Title)
+		// Successful results are quiet, unless verbose.
+		require.Equal(t, lint.Quiet, results["library-panel-rule"][0].Result.Results[0].Severity)
+	})
+
+	t.Run("unresolved references", func(t *testing.T) {
+		linter, err := lint.NewLinter(lint.WithRules(lint.NewPanelTitleDescriptionRule()))
+		require.NoError(t, err)
+		res, err := linter.Lint([]byte(libraryPanelDashboard))
+		require.NoError(t, err)
+		// Only the row is linted, the references have nothing to lint.
+		require.Len(t, res.Results.Results(), 1)
+		require.Equal(t, "row", res.Results.Results()[0].Panel.Title)
+	})
+}
+
+func TestLinterLibraryPanelV2(t *testing.T) {
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewTargetRateIntervalRule()),
+		lint.WithLibraryPanels(lint.LibraryPanels{"cpu": mustParseLibraryPanel(t, libraryPanel)}),
+	)
+	require.NoError(t, err)
+
+	res, err := linter.Lint([]byte(`{
+		"apiVersion": "dashboard.grafana.app/v2",
+		"kind": "Dashboard",
+		"spec": {
+			"title": "v2",
+			"variables": [ { "kind": "DatasourceVariable", "spec": { "name": "datasource", "pluginId": "prometheus" } } ],
+			"elements": {
+				"cpu": { "kind": "LibraryPanel", "spec": { "id": 1, "title": "CPU", "libraryPanel": { "uid": "cpu", "name": "CPU" } } }
+			},
+			"layout": { "kind": "GridLayout", "spec": { "items": [] } }
+		}
+	}`))
+	require.NoError(t, err)
+	results := res.Results.Results()
+	require.Len(t, results, 1)
+	require.Equal(t, "cpu", results[0].LibraryPanel.UID)
+	require.Equal(t, lint.Error, results[0].Result.Results[0].Severity)
+}
+
+func mustParseLibraryPanel(t *testing.T, buf string) *lint.LibraryPanel {
+	t.Helper()
+	lp, err := lint.ParseLibraryPanel([]byte(buf))
+	require.NoError(t, err)
+	return lp
+}
+
+func TestLinterLintLibraryPanel(t *testing.T) {
+	config := lint.NewConfigurationFile()
+	config.Autofix = true
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewUneditableRule(), lint.NewTargetRateIntervalRule()),
+		lint.WithConfig(config),
+	)
+	require.NoError(t, err)
+
+	for name, buf := range map[string]string{
+		"exported":     libraryPanel,
+		"api response": `{ "result": ` + libraryPanel + ` }`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			res, err := linter.LintLibraryPanel("cpu.json", []byte(buf))
+			require.NoError(t, err)
+
+			// Dashboard rules are not run for a library panel.
+			results := res.Results.Results()
+			require.Len(t, results, 1)
+			require.Equal(t, "target-rate-interval-rule", results[0].Rule.Name())
+			require.Equal(t, "cpu", results[0].LibraryPanel.UID)
+			require.Equal(t, lint.Fixed, results[0].Result.Results[0].Severity)
+			require.Contains(t, results[0].Result.Results[0].Message, "Library panel 'CPU', target idx '0'")
+
+			expected := strings.Replace(buf, "[5m]", "[$__rate_interval]", 1)
+			require.Equal(t, expected, string(res.Fixed))
+		})
+	}
+
+	_, err = linter.LintLibraryPanel("", []byte(libraryPanelDashboard))
+	require.ErrorContains(t, err, "not a library panel")
+}
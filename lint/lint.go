@@ -30,11 +30,40 @@ type Template struct {
 	Query      string             `json:"-"`
 	Datasource interface{}        `json:"datasource,omitempty"`
 	Multi      bool               `json:"multi"`
+	IncludeAll bool               `json:"includeAll,omitempty"`
 	AllValue   string             `json:"allValue,omitempty"`
 	Current    RawTemplateValue   `json:"current"`
 	Options    []RawTemplateValue `json:"options"`
 	Refresh    int                `json:"refresh"`
-	// If you add properties here don't forget to add them to the raw struct, and assign them from raw to actual in UnmarshalJSON below!
+
+	// DefaultValue is the value of a group by variable when it is reset.
+	DefaultValue RawTemplateValue `json:"defaultValue,omitempty"`
+	// Filters and BaseFilters are the filters of an ad hoc variable.
+	Filters     []AdHocFilter `json:"filters,omitempty"`
+	BaseFilters []AdHocFilter `json:"baseFilters,omitempty"`
+
+	fields jsonFields
+}
+
+// AdHocFilter is a label filter of an ad hoc variable, e.g. job = "node".
+type AdHocFilter struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+
+	fields jsonFields
+}
+
+func (f *AdHocFilter) UnmarshalJSON(buf []byte) error {
+	type filter AdHocFilter
+	fields, err := unmarshalObject(buf, (*filter)(f))
+	f.fields = fields
+	return err
+}
+
+func (f AdHocFilter) MarshalJSON() ([]byte, error) {
+	type filter AdHocFilter
+	return marshalObject(filter(f), f.fields, nil)
 }
 
 type RawTemplateValue map[string]interface{}
@@ -45,36 +74,20 @@ type TemplateValue struct {
 }
 
 func (t *Template) UnmarshalJSON(buf []byte) error {
-	var raw struct {
-		Name       string             `json:"name"`
-		Label      string             `json:"label"`
-		Type       string             `json:"type"`
-		Query      interface{}        `json:"query"`
-		Datasource interface{}        `json:"datasource,omitempty"`
-		Multi      bool               `json:"multi"`
-		AllValue   string             `json:"allValue"`
-		Current    RawTemplateValue   `json:"current"`
-		Options    []RawTemplateValue `json:"options"`
-		Refresh    int                `json:"refresh"`
-	}
-	if err := json.Unmarshal(buf, &raw); err != nil {
+	type template Template
+	var raw template
+	fields, err := unmarshalObject(buf, &raw)
+	if err != nil {
 		return err
 	}
+	*t = Template(raw)
+	t.fields = fields
 
-	t.Name = raw.Name
-	t.Label = raw.Label
-	t.Type = raw.Type
-	t.Datasource = raw.Datasource
-	t.Multi = raw.Multi
-	t.AllValue = raw.AllValue
-	t.Current = raw.Current
-	t.Options = raw.Options
-	t.Refresh = raw.Refresh
-	t.RawQuery = raw.Query
-
-	// the 'adhoc' and 'custom' variable type does not have a field `Query`, so we can't perform these checks
-	if t.Type != "adhoc" && t.Type != "custom" {
-		switch v := raw.Query.(type) {
+	// the 'adhoc', 'groupby', 'switch' and 'custom' variable types do not have a field `Query`, so we can't perform these checks
+	switch t.Type {
+	case "adhoc", "groupby", "switch", "custom":
+	default:
+		switch v := t.RawQuery.(type) {
 		case string:
 			t.Query = v
 		case map[string]interface{}:
@@ -90,6 +103,11 @@ func (t *Template) UnmarshalJSON(buf []byte) error {
 	return nil
 }
 
+func (t Template) MarshalJSON() ([]byte, error) {
+	type template Template
+	return marshalObject(template(t), t.fields, nil)
+}
+
 func (t *Template) GetDataSource() (Datasource, error) {
 	return GetDataSource(t.Datasource)
 }
@@ -133,6 +151,20 @@ type Input struct {
 	Label    string `json:"label"`
 	Type     string `json:"type"`
 	PluginID string `json:"pluginId"`
+
+	fields jsonFields
+}
+
+func (i *Input) UnmarshalJSON(buf []byte) error {
+	type input Input
+	fields, err := unmarshalObject(buf, (*input)(i))
+	i.fields = fields
+	return err
+}
+
+func (i Input) MarshalJSON() ([]byte, error) {
+	type input Input
+	return marshalObject(input(i), i.fields, nil)
 }
 
 type Datasource struct {
@@ -177,6 +209,24 @@ type Target struct {
 	PanelId    int         `json:"panelId,omitempty"`
 	RefId      string      `json:"refId,omitempty"`
 	Hide       bool        `json:"hide"`
+	// LegendFormat, Interval and Format are Prometheus query options.
+	LegendFormat string `json:"legendFormat,omitempty"`
+	Interval     string `json:"interval,omitempty"`
+	Format       string `json:"format,omitempty"`
+
+	fields jsonFields
+}
+
+func (t *Target) UnmarshalJSON(buf []byte) error {
+	type target Target
+	fields, err := unmarshalObject(buf, (*target)(t))
+	t.fields = fields
+	return err
+}
+
+func (t Target) MarshalJSON() ([]byte, error) {
+	type target Target
+	return marshalObject(target(t), t.fields, nil)
 }
 
 func (t *Target) GetDataSource() (Datasource, error) {
@@ -186,6 +236,20 @@ func (t *Target) GetDataSource() (Datasource, error) {
 type Annotation struct {
 	Name       string      `json:"name"`
 	Datasource interface{} `json:"datasource,omitempty"`
+
+	fields jsonFields
+}
+
+func (a *Annotation) UnmarshalJSON(buf []byte) error {
+	type annotation Annotation
+	fields, err := unmarshalObject(buf, (*annotation)(a))
+	a.fields = fields
+	return err
+}
+
+func (a Annotation) MarshalJSON() ([]byte, error) {
+	type annotation Annotation
+	return marshalObject(annotation(a), a.fields, nil)
 }
 
 func (a *Annotation) GetDataSource() (Datasource, error) {
@@ -204,15 +268,159 @@ type Panel struct {
 	Panels      []Panel         `json:"panels,omitempty"`
 	FieldConfig *FieldConfig    `json:"fieldConfig,omitempty"`
 	Options     json.RawMessage `json:"options,omitempty"`
+
+	GridPos         *GridPos         `json:"gridPos,omitempty"`
+	Repeat          string           `json:"repeat,omitempty"`
+	RepeatDirection string           `json:"repeatDirection,omitempty"`
+	MaxDataPoints   int              `json:"maxDataPoints,omitempty"`
+	Interval        string           `json:"interval,omitempty"`
+	TimeFrom        string           `json:"timeFrom,omitempty"`
+	TimeShift       string           `json:"timeShift,omitempty"`
+	Transformations []Transformation `json:"transformations,omitempty"`
+	LibraryPanel    *LibraryPanelRef `json:"libraryPanel,omitempty"`
+	Links           []PanelLink      `json:"links,omitempty"`
+
+	// pointer is the JSON pointer of the panel in its dashboard, set by Dashboard.GetPanels. Fixes
+	// use it to find the panel, wherever it is nested.
+	pointer string
+	// library is the library panel the panel was resolved with, see Dashboard.ResolveLibraryPanels.
+	library *LibraryPanel
+	// section names the rows and tabs of a v2 layout the panel is in, e.g. "row 'Overview'".
+	section string
+	fields  jsonFields
+}
+
+func (p *Panel) UnmarshalJSON(buf []byte) error {
+	type panel Panel
+	fields, err := unmarshalObject(buf, (*panel)(p))
+	p.fields = fields
+	return err
+}
+
+func (p Panel) MarshalJSON() ([]byte, error) {
+	type panel Panel
+	return marshalObject(panel(p), p.fields, nil)
+}
+
+// GridPos is the position and size of a panel in the dashboard grid, which is 24 columns wide.
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+
+	fields jsonFields
+}
+
+func (g *GridPos) UnmarshalJSON(buf []byte) error {
+	type gridPos GridPos
+	fields, err := unmarshalObject(buf, (*gridPos)(g))
+	g.fields = fields
+	return err
+}
+
+func (g GridPos) MarshalJSON() ([]byte, error) {
+	type gridPos GridPos
+	return marshalObject(gridPos(g), g.fields, nil)
+}
+
+// Transformation is a transformation of the data of a panel.
+type Transformation struct {
+	Id       string          `json:"id"`
+	Disabled bool            `json:"disabled,omitempty"`
+	Options  json.RawMessage `json:"options,omitempty"`
+
+	fields jsonFields
+}
+
+func (t *Transformation) UnmarshalJSON(buf []byte) error {
+	type transformation Transformation
+	fields, err := unmarshalObject(buf, (*transformation)(t))
+	t.fields = fields
+	return err
+}
+
+func (t Transformation) MarshalJSON() ([]byte, error) {
+	type transformation Transformation
+	return marshalObject(transformation(t), t.fields, nil)
+}
+
+// LibraryPanelRef is the reference of a panel to the library panel it is an instance of.
+type LibraryPanelRef struct {
+	UID  string `json:"uid"`
+	Name string `json:"name,omitempty"`
+
+	fields jsonFields
+}
+
+func (l *LibraryPanelRef) UnmarshalJSON(buf []byte) error {
+	type libraryPanelRef LibraryPanelRef
+	fields, err := unmarshalObject(buf, (*libraryPanelRef)(l))
+	l.fields = fields
+	return err
+}
+
+func (l LibraryPanelRef) MarshalJSON() ([]byte, error) {
+	type libraryPanelRef LibraryPanelRef
+	return marshalObject(libraryPanelRef(l), l.fields, nil)
+}
+
+// PanelLink is a link in the header of a panel.
+type PanelLink struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	TargetBlank bool   `json:"targetBlank,omitempty"`
+
+	fields jsonFields
+}
+
+func (l *PanelLink) UnmarshalJSON(buf []byte) error {
+	type panelLink PanelLink
+	fields, err := unmarshalObject(buf, (*panelLink)(l))
+	l.fields = fields
+	return err
+}
+
+func (l PanelLink) MarshalJSON() ([]byte, error) {
+	type panelLink PanelLink
+	return marshalObject(panelLink(l), l.fields, nil)
 }
 
 type FieldConfig struct {
 	Defaults  Defaults   `json:"defaults,omitempty"`
 	Overrides []Override `json:"overrides,omitempty"`
+
+	fields jsonFields
+}
+
+func (c *FieldConfig) UnmarshalJSON(buf []byte) error {
+	type fieldConfig FieldConfig
+	fields, err := unmarshalObject(buf, (*fieldConfig)(c))
+	c.fields = fields
+	return err
+}
+
+func (c FieldConfig) MarshalJSON() ([]byte, error) {
+	type fieldConfig FieldConfig
+	return marshalObject(fieldConfig(c), c.fields, nil)
 }
 
 type Override struct {
 	OverrideProperties []OverrideProperty `json:"properties"`
+
+	fields jsonFields
+}
+
+func (o *Override) UnmarshalJSON(buf []byte) error {
+	type override Override
+	fields, err := unmarshalObject(buf, (*override)(o))
+	o.fields = fields
+	return err
+}
+
+func (o Override) MarshalJSON() ([]byte, error) {
+	type override Override
+	return marshalObject(override(o), o.fields, nil)
 }
 
 type OverrideProperty struct {
@@ -235,8 +443,65 @@ type StatOptions struct {
 }
 
 type Defaults struct {
-	Unit     string          `json:"unit,omitempty"`
-	Mappings json.RawMessage `json:"mappings,omitempty"`
+	Unit       string          `json:"unit,omitempty"`
+	Mappings   json.RawMessage `json:"mappings,omitempty"`
+	Thresholds *Thresholds     `json:"thresholds,omitempty"`
+
+	fields jsonFields
+}
+
+func (d *Defaults) UnmarshalJSON(buf []byte) error {
+	type defaults Defaults
+	fields, err := unmarshalObject(buf, (*defaults)(d))
+	d.fields = fields
+	return err
+}
+
+func (d Defaults) MarshalJSON() ([]byte, error) {
+	type defaults Defaults
+	return marshalObject(defaults(d), d.fields, nil)
+}
+
+// Thresholds are the thresholds of the values of a field.
+type Thresholds struct {
+	// Mode is "absolute" or "percentage".
+	Mode  string          `json:"mode"`
+	Steps []ThresholdStep `json:"steps"`
+
+	fields jsonFields
+}
+
+func (t *Thresholds) UnmarshalJSON(buf []byte) error {
+	type thresholds Thresholds
+	fields, err := unmarshalObject(buf, (*thresholds)(t))
+	t.fields = fields
+	return err
+}
+
+func (t Thresholds) MarshalJSON() ([]byte, error) {
+	type thresholds Thresholds
+	return marshalObject(thresholds(t), t.fields, nil)
+}
+
+// ThresholdStep is a threshold, from its value on. The value of the first step is null, as it
+// is the base.
+type ThresholdStep struct {
+	Color string   `json:"color"`
+	Value *float64 `json:"value"`
+
+	fields jsonFields
+}
+
+func (t *ThresholdStep) UnmarshalJSON(buf []byte) error {
+	type thresholdStep ThresholdStep
+	fields, err := unmarshalObject(buf, (*thresholdStep)(t))
+	t.fields = fields
+	return err
+}
+
+func (t ThresholdStep) MarshalJSON() ([]byte, error) {
+	type thresholdStep ThresholdStep
+	return marshalObject(thresholdStep(t), t.fields, nil)
 }
 
 // GetPanels returns the all panels nested inside the panel (inc the current panel)
@@ -256,6 +521,20 @@ func (p *Panel) GetDataSource() (Datasource, error) {
 // The properties which are extracted from JSON are only those used for linting purposes.
 type Row struct {
 	Panels []Panel `json:"panels,omitempty"`
+
+	fields jsonFields
+}
+
+func (r *Row) UnmarshalJSON(buf []byte) error {
+	type row Row
+	fields, err := unmarshalObject(buf, (*row)(r))
+	r.fields = fields
+	return err
+}
+
+func (r Row) MarshalJSON() ([]byte, error) {
+	type row Row
+	return marshalObject(row(r), r.fields, nil)
 }
 
 // GetPanels returns the all panels nested inside the row
@@ -269,6 +548,8 @@ func (r *Row) GetPanels() []Panel {
 
 // Dashboard is a deliberately incomplete representation of the Dashboard type in grafana.
 // The properties which are extracted from JSON are only those used for linting purposes.
+// The JSON members which are not extracted are kept though, in the dashboard and the types it is
+// made of, so a dashboard marshals to the JSON it was read from, apart from the changes made to it.
 type Dashboard struct {
 	Inputs     []Input `json:"__inputs"`
 	Title      string  `json:"title,omitempty"`
@@ -280,12 +561,126 @@ type Dashboard struct {
 	} `json:"annotations"`
 	Rows     []Row   `json:"rows,omitempty"`
 	Panels   []Panel `json:"panels,omitempty"`
-	Editable bool    `json:"editable,omitempty"`
+	Editable bool    `json:"editable"`
+
+	UID           string          `json:"uid,omitempty"`
+	Tags          []string        `json:"tags,omitempty"`
+	SchemaVersion int             `json:"schemaVersion,omitempty"`
+	Time          *TimeRange      `json:"time,omitempty"`
+	Refresh       interface{}     `json:"refresh,omitempty"` // A refresh interval, or false for none, use GetRefresh.
+	Timezone      string          `json:"timezone,omitempty"`
+	GraphTooltip  int             `json:"graphTooltip,omitempty"`
+	Links         []DashboardLink `json:"links,omitempty"`
 
 	// Kubernetes shaped dashboards will include an APIVersion and Kind
 	APIVersion string `json:"apiVersion,omitempty"`
 	// When reading a kubernetes encoded dashboard, the Dashboard will be
 	Spec json.RawMessage `json:"spec,omitempty"`
+
+	// Source is where the dashboard was read from, e.g. its file name, if known. Reporters use
+	// it to locate results.
+	Source string `json:"-"`
+
+	// raw is the JSON the dashboard was parsed from, if it has the classic dashboard shape.
+	raw []byte
+	// v2 maps the nodes to the v2 spec the dashboard was built from, if any.
+	v2 *v2Source
+	// libraryPanels are the library panels the references of the dashboard were resolved with.
+	libraryPanels LibraryPanels
+	// library is the library panel the dashboard was built from to lint it on its own, if any.
+	library *LibraryPanel
+	// fields, templatingFields and annotationsFields keep what the model does not know about the
+	// dashboard, its templating and its annotations.
+	fields, templatingFields, annotationsFields jsonFields
+}
+
+func (d *Dashboard) UnmarshalJSON(buf []byte) error {
+	type dashboard Dashboard
+	fields, err := unmarshalObject(buf, (*dashboard)(d))
+	if err != nil {
+		return err
+	}
+	d.fields = fields
+
+	// The templating and annotations are anonymous structs, so the dashboard keeps their fields.
+	var nested struct {
+		Templating  json.RawMessage `json:"templating"`
+		Annotations json.RawMessage `json:"annotations"`
+	}
+	if err := json.Unmarshal(buf, &nested); err != nil {
+		return err
+	}
+	if nested.Templating != nil {
+		if d.templatingFields, err = unmarshalObject(nested.Templating, &d.Templating); err != nil {
+			return err
+		}
+	}
+	if nested.Annotations != nil {
+		if d.annotationsFields, err = unmarshalObject(nested.Annotations, &d.Annotations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d Dashboard) MarshalJSON() ([]byte, error) {
+	type dashboard Dashboard
+	return marshalObject(dashboard(d), d.fields, map[string]jsonFields{
+		"templating":  d.templatingFields,
+		"annotations": d.annotationsFields,
+	})
+}
+
+// TimeRange is the default time range of a dashboard, e.g. from "now-6h" to "now".
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	fields jsonFields
+}
+
+func (t *TimeRange) UnmarshalJSON(buf []byte) error {
+	type timeRange TimeRange
+	fields, err := unmarshalObject(buf, (*timeRange)(t))
+	t.fields = fields
+	return err
+}
+
+func (t TimeRange) MarshalJSON() ([]byte, error) {
+	type timeRange TimeRange
+	return marshalObject(timeRange(t), t.fields, nil)
+}
+
+// DashboardLink is a link in the header of a dashboard, to a URL or to the dashboards with the tags.
+type DashboardLink struct {
+	Title       string   `json:"title"`
+	Type        string   `json:"type"`
+	URL         string   `json:"url,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	AsDropdown  bool     `json:"asDropdown,omitempty"`
+	IncludeVars bool     `json:"includeVars,omitempty"`
+	KeepTime    bool     `json:"keepTime,omitempty"`
+	TargetBlank bool     `json:"targetBlank,omitempty"`
+
+	fields jsonFields
+}
+
+func (l *DashboardLink) UnmarshalJSON(buf []byte) error {
+	type dashboardLink DashboardLink
+	fields, err := unmarshalObject(buf, (*dashboardLink)(l))
+	l.fields = fields
+	return err
+}
+
+func (l DashboardLink) MarshalJSON() ([]byte, error) {
+	type dashboardLink DashboardLink
+	return marshalObject(dashboardLink(l), l.fields, nil)
+}
+
+// GetRefresh returns the auto refresh interval of the dashboard, or "" if it does not refresh.
+func (d *Dashboard) GetRefresh() string {
+	refresh, _ := d.Refresh.(string)
+	return refresh
 }
 
 // GetPanels returns the all panels whether they are nested in the (now deprecated) "rows" property or
@@ -293,12 +688,10 @@ type Dashboard struct {
 // to uniquely identify panel targets while linting.
 func (d *Dashboard) GetPanels() []Panel {
 	var p []Panel
-	for _, row := range d.Rows {
-		p = append(p, row.GetPanels()...)
-	}
-	for _, panel := range d.Panels {
-		p = append(p, panel.GetPanels()...)
+	for ri, row := range d.Rows {
+		p = append(p, flattenPanels(row.Panels, fmt.Sprintf("/rows/%d/panels", ri))...)
 	}
+	p = append(p, flattenPanels(d.Panels, "/panels")...)
 	for pi, pa := range p {
 		for ti := range pa.Targets {
 			p[pi].Targets[ti].Idx = ti
@@ -307,6 +700,62 @@ func (d *Dashboard) GetPanels() []Panel {
 	return p
 }
 
+// flattenPanels returns the panels and the panels nested in them, depth first, with their JSON
+// pointers below the given pointer of the list. Library panel references which have not been
+// resolved, and are only a reference, are left out, as they have nothing to lint. Older versions
+// of Grafana save the model of the library panel along with the reference, which is linted.
+func flattenPanels(panels []Panel, pointer string) []Panel {
+	var flat []Panel
+	for i, panel := range panels {
+		if isLibraryPanelReference(panel) {
+			continue
+		}
+		panel.pointer = fmt.Sprintf("%s/%d", pointer, i)
+		flat = append(flat, panel)
+		flat = append(flat, flattenPanels(panel.Panels, panel.pointer+"/panels")...)
+	}
+	return flat
+}
+
+// panelAt returns the panel at the JSON pointer set by GetPanels, or nil if there is none.
+func (d *Dashboard) panelAt(pointer string) *Panel {
+	tokens, err := parsePointer(pointer)
+	if err != nil || len(tokens) < 2 {
+		return nil
+	}
+	var panels []Panel
+	switch tokens[0] {
+	case "rows":
+		ri, err := arrayIndex(tokens[1], len(d.Rows), false)
+		if err != nil || len(tokens) < 4 || tokens[2] != "panels" {
+			return nil
+		}
+		panels = d.Rows[ri].Panels
+		tokens = tokens[3:]
+	case "panels":
+		panels = d.Panels
+		tokens = tokens[1:]
+	default:
+		return nil
+	}
+
+	// tokens is now a sequence of index, "panels", index, ...
+	for {
+		i, err := arrayIndex(tokens[0], len(panels), false)
+		if err != nil {
+			return nil
+		}
+		if len(tokens) == 1 {
+			return &panels[i]
+		}
+		if len(tokens) < 3 || tokens[1] != "panels" {
+			return nil
+		}
+		panels = panels[i].Panels
+		tokens = tokens[2:]
+	}
+}
+
 // GetTemplateByType returns all dashboard templates which match the provided type. Type comparison
 // is case insensitive as it uses strings.EqualFold()
 func (d *Dashboard) GetTemplateByType(t string) []Template {
@@ -333,10 +782,10 @@ func NewDashboard(buf []byte) (Dashboard, error) {
 		apiVersion := dash.APIVersion
 		// The v2 schema is structurally different and handled by its own adapter.
 		if isV2APIVersion(apiVersion) {
-			return newDashboardFromV2(dash.Spec, apiVersion)
+			return newDashboardFromV2(dash.Spec, apiVersion, dash.fields.unknown["metadata"])
 		}
 		if apiVersion != "" {
-			if !strings.HasPrefix(apiVersion, "v0") && !strings.HasPrefix(apiVersion, "v1") {
+			if v := apiVersionOf(apiVersion); !strings.HasPrefix(v, "v0") && !strings.HasPrefix(v, "v1") {
 				return dash, fmt.Errorf("unsupported apiVersion")
 			}
 		}
@@ -344,6 +793,9 @@ func NewDashboard(buf []byte) (Dashboard, error) {
 			return dash, err
 		}
 		dash.APIVersion = apiVersion // preserve the original APIVersion
+		dash.raw = dash.Spec
+		return dash, nil
 	}
+	dash.raw = buf
 	return dash, nil
 }
@@ -0,0 +1,252 @@
+package lint
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Linter lints dashboard JSON with a set of rules and a configuration, and reports the results.
+// It is the entry point for embedding the linter in other programs; the command line tool is
+// built on it as well.
+type Linter struct {
+	rules   RuleSet
+	config  *ConfigurationFile
+	outputs []output
+	ctx     context.Context
+	// libraryPanels resolve the library panel references of the dashboards, if set.
+	libraryPanels LibraryPanels
+	// collected holds the results of the linted dashboards until they are reported on Close.
+	collected ResultSet
+}
+
+type output struct {
+	reporter Reporter
+	w        io.Writer
+}
+
+// Option configures a Linter.
+type Option func(*Linter)
+
+// WithRules replaces the built-in rules. Custom rules and plugins declared in the
+// configuration are added to them.
+func WithRules(rules ...Rule) Option {
+	return func(l *Linter) {
+		l.rules = RuleSet{rules: rules}
+	}
+}
+
+// WithConfig sets the configuration applied to the results. Fixes are only applied if
+// Autofix is set.
+func WithConfig(c *ConfigurationFile) Option {
+	return func(l *Linter) {
+		l.config = c
+	}
+}
+
+// WithOutput adds a writer which the results of every linted dashboard are reported to,
+// grouped by rule.
+func WithOutput(w io.Writer) Option {
+	return WithReporter(TTYReporter{}, w)
+}
+
+// WithReporter adds a reporter which writes the results of every linted dashboard to w.
+// Several reporters may be added, e.g. to write a human readable log and machine readable
+// artifacts in a single run. Except for the TTY reporter, which writes the results of each
+// dashboard as it is linted, reporters write a single document when the linter is closed.
+func WithReporter(r Reporter, w io.Writer) Option {
+	return func(l *Linter) {
+		l.outputs = append(l.outputs, output{reporter: r, w: w})
+	}
+}
+
+// WithContext sets a context which cancels linting, including running rule plugins.
+func WithContext(ctx context.Context) Option {
+	return func(l *Linter) {
+		l.ctx = ctx
+	}
+}
+
+// WithLibraryPanels resolves the library panel references of the dashboards with the library
+// panels before linting, see Dashboard.ResolveLibraryPanels.
+func WithLibraryPanels(lib LibraryPanels) Option {
+	return func(l *Linter) {
+		l.libraryPanels = lib
+	}
+}
+
+// NewLinter creates a Linter with the built-in rules and an empty configuration, unless
+// overridden by the options.
+func NewLinter(opts ...Option) (*Linter, error) {
+	l := &Linter{
+		rules:  NewRuleSet(),
+		config: NewConfigurationFile(),
+		ctx:    context.Background(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	customRules, err := l.config.CustomRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load custom rules: %w", err)
+	}
+	for _, r := range customRules {
+		l.rules.Add(r)
+	}
+	return l, nil
+}
+
+// Rules returns the rules the linter runs, including custom rules and plugins.
+func (l *Linter) Rules() []Rule {
+	return l.rules.Rules()
+}
+
+// LintResult is the outcome of linting a single dashboard.
+type LintResult struct {
+	// Dashboard is the parsed dashboard, with fixes applied if any.
+	Dashboard Dashboard
+	Results   *ResultSet
+	// Fixed is the fixed dashboard JSON. It is nil unless Autofix is configured and at least one
+	// result was fixed, or would be in a dry run.
+	Fixed []byte
+}
+
+// Lint parses and lints the dashboard JSON, applies fixes if configured, and reports the
+// results to the outputs of the linter.
+func (l *Linter) Lint(buf []byte) (*LintResult, error) {
+	return l.LintSource("", buf)
+}
+
+// LintSource lints the dashboard JSON like Lint, and records where it was read from so
+// reporters can locate the results.
+func (l *Linter) LintSource(source string, buf []byte) (*LintResult, error) {
+	dashboard, err := NewDashboard(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dashboard: %w", err)
+	}
+	dashboard.Source = source
+	if l.libraryPanels != nil {
+		dashboard.ResolveLibraryPanels(l.libraryPanels)
+	}
+
+	results, err := l.rules.LintContext(l.ctx, []Dashboard{dashboard})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lint dashboard: %w", err)
+	}
+	return l.finish(l.rules, dashboard, results, buf)
+}
+
+// LintLibraryPanel lints an exported library panel on its own, with the panel and target rules.
+// The results are about a dashboard which has the library panel as its only panel, along with
+// the datasource variables it uses, and fixes are applied to the model of the library panel.
+func (l *Linter) LintLibraryPanel(source string, buf []byte) (*LintResult, error) {
+	lp, err := ParseLibraryPanel(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse library panel: %w", err)
+	}
+	lp.Source = source
+
+	model := lp.Model
+	model.LibraryPanel = nil
+	dashboard := Dashboard{Title: lp.Name, Panels: []Panel{model}, Source: source, library: lp}
+	dashboard.Templating.List = libraryPanelTemplates(model)
+
+	var rules RuleSet
+	for _, r := range l.rules.Rules() {
+		if c := GetMetadata(r).Category; c == CategoryPanel || c == CategoryTarget {
+			rules.Add(r)
+		}
+	}
+	results, err := rules.LintContext(l.ctx, []Dashboard{dashboard})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lint library panel: %w", err)
+	}
+	return l.finish(rules, dashboard, results, buf)
+}
+
+// maxFixPasses limits how often a dashboard is linted again to apply the fixes its fixes enable.
+const maxFixPasses = 5
+
+// finish applies the fixes if configured, and reports the results of the dashboard read from buf,
+// which the rules were run on.
+func (l *Linter) finish(rules RuleSet, dashboard Dashboard, results *ResultSet, buf []byte) (*LintResult, error) {
+	res := &LintResult{}
+	// The configuration is applied first, so results it excludes or downgrades are not fixed.
+	results.Configure(l.config)
+	if l.config.Autofix {
+		before, err := dashboard.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fix dashboard: %w", err)
+		}
+		// A fix can make others possible, e.g. the job variable can only be added once there is a
+		// datasource variable, so the fixed dashboard is linted again until nothing is fixed. The
+		// results are those of the last run, along with what the earlier runs fixed.
+		fixed := autoFix(results, &dashboard)
+		total := fixed
+		for pass := 1; fixed > 0 && pass < maxFixPasses; pass++ {
+			next, err := rules.LintContext(l.ctx, []Dashboard{dashboard})
+			if err != nil {
+				return nil, fmt.Errorf("failed to lint fixed dashboard: %w", err)
+			}
+			next.Configure(l.config)
+			next.carryFixed(results)
+			results = next
+			fixed = autoFix(results, &dashboard)
+			total += fixed
+		}
+		if total > 0 {
+			res.Fixed, err = fixedJSON(before, dashboard, buf)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fix dashboard: %w", err)
+			}
+		}
+		if l.config.DryRun {
+			results.unfix()
+		}
+	}
+	res.Dashboard = dashboard
+	res.Results = results
+
+	collect := false
+	for _, o := range l.outputs {
+		if _, ok := o.reporter.(streamingReporter); !ok {
+			collect = true
+			continue
+		}
+		if err := o.reporter.Report(o.w, results); err != nil {
+			return nil, fmt.Errorf("failed to report results: %w", err)
+		}
+	}
+	if collect {
+		l.collected.results = append(l.collected.results, results.results...)
+	}
+	return res, nil
+}
+
+// autoFix applies the fixes of the results to the dashboard, except those which cannot be written
+// to the JSON it was read from.
+func autoFix(results *ResultSet, dashboard *Dashboard) int {
+	if dashboard.v2 != nil {
+		results.dropUnsupportedFixes(*dashboard)
+	}
+	return results.AutoFix(dashboard)
+}
+
+// Close writes the results of every dashboard linted since the last Close to the reporters which
+// write a single document, such as the JSON and SARIF ones. It must be called once linting is
+// done, even if only one dashboard was linted.
+func (l *Linter) Close() error {
+	results := l.collected
+	results.config = l.config
+	l.collected = ResultSet{}
+	for _, o := range l.outputs {
+		if _, ok := o.reporter.(streamingReporter); ok {
+			continue
+		}
+		if err := o.reporter.Report(o.w, &results); err != nil {
+			return fmt.Errorf("failed to report results: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package lint_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/dashboard-linter/lint"
+)
+
+const linterDashboard = `{
+	"title": "test",
+	"editable": true,
+	"custom": { "kept": true },
+	"templating": { "list": [ { "name": "job", "type": "query", "query": "label_values(up, job)", "refresh": 1 } ] },
+	"panels": [ { "id": 1, "type": "stat", "title": "stat" } ]
+}`
+
+func TestLinter(t *testing.T) {
+	var out bytes.Buffer
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewUneditableRule()),
+		lint.WithOutput(&out),
+	)
+	require.NoError(t, err)
+	require.Len(t, linter.Rules(), 1)
+
+	res, err := linter.Lint([]byte(linterDashboard))
+	require.NoError(t, err)
+	require.Nil(t, res.Fixed)
+	require.Equal(t, "test", res.Dashboard.Title)
+	require.Equal(t, lint.Error, res.Results.MaximumSeverity())
+	require.Len(t, res.Results.Results(), 1)
+	require.Equal(t, "uneditable-dashboard", res.Results.Results()[0].Rule.Name())
+	require.Contains(t, out.String(), "Dashboard 'test' is editable, it should be set to 'editable: false'")
+}
+
+func TestLinterClose(t *testing.T) {
+	var out bytes.Buffer
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewUneditableRule()),
+		lint.WithReporter(lint.JSONReporter{}, &out),
+	)
+	require.NoError(t, err)
+
+	_, err = linter.LintSource("a.json", []byte(linterDashboard))
+	require.NoError(t, err)
+	_, err = linter.LintSource("b.json", []byte(linterDashboard))
+	require.NoError(t, err)
+	require.Zero(t, out.Len())
+
+	// The results of both dashboards are written as one document.
+	require.NoError(t, linter.Close())
+	var report struct {
+		Results []struct {
+			Source string `json:"source"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Len(t, report.Results, 2)
+	require.Equal(t, "a.json", report.Results[0].Source)
+	require.Equal(t, "b.json", report.Results[1].Source)
+}
+
+func TestLinterAutofix(t *testing.T) {
+	config := lint.NewConfigurationFile()
+	config.Autofix = true
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewUneditableRule(), lint.NewTemplateOnTimeRangeReloadRule()),
+		lint.WithConfig(config),
+	)
+	require.NoError(t, err)
+
+	res, err := linter.Lint([]byte(linterDashboard))
+	require.NoError(t, err)
+	require.Equal(t, 2, res.Dashboard.Templating.List[0].Refresh)
+	require.Equal(t, lint.Fixed, res.Results.MaximumSeverity())
+
+	// Only the fixed values change, the formatting, key order and unknown fields are kept.
+	expected := strings.NewReplacer(
+		`"editable": true`, `"editable": false`,
+		`"refresh": 1`, `"refresh": 2`,
+	).Replace(linterDashboard)
+	require.Equal(t, expected, string(res.Fixed))
+}
+
+func TestLinterAutofixConfigured(t *testing.T) {
+	config := lint.NewConfigurationFile()
+	config.Autofix = true
+	config.Exclusions["uneditable-dashboard"] = nil
+	config.Warnings["template-on-time-change-reload-rule"] = nil
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewUneditableRule(), lint.NewTemplateOnTimeRangeReloadRule()),
+		lint.WithConfig(config),
+	)
+	require.NoError(t, err)
+
+	// Excluded and downgraded results are accepted by the configuration, so they are not fixed.
+	res, err := linter.Lint([]byte(linterDashboard))
+	require.NoError(t, err)
+	require.Nil(t, res.Fixed)
+	require.Equal(t, lint.Warning, res.Results.MaximumSeverity())
+	summary := res.Results.Summary()
+	require.Equal(t, 1, summary.Total.Excluded)
+	require.Equal(t, 1, summary.Total.Warning)
+	require.Zero(t, summary.FixesApplied)
+	require.Zero(t, summary.FixesAvailable)
+}
+
+func TestLinterAutofixWithoutVariables(t *testing.T) {
+	config := lint.NewConfigurationFile()
+	config.Autofix = true
+	linter, err := lint.NewLinter(
+		lint.WithRules(
+			lint.NewTemplateDatasourceRule(),
+			lint.NewTemplateJobRule(),
+			lint.NewTemplateInstanceRule(),
+			lint.NewTargetJobRule(),
+			lint.NewTargetInstanceRule(),
+		),
+		lint.WithConfig(config),
+	)
+	require.NoError(t, err)
+
+	res, err := linter.Lint([]byte(`{
+		"title": "test",
+		"panels": [ {
+			"id": 1, "type": "timeseries", "title": "up",
+			"datasource": { "type": "prometheus", "uid": "prom-1" },
+			"targets": [ { "refId": "A", "expr": "sum(up)" } ]
+		} ]
+	}`))
+	require.NoError(t, err)
+
+	// A single run adds the datasource, then job and instance, and then uses them in the query.
+	f, err := lint.NewDashboard(res.Fixed)
+	require.NoError(t, err)
+	var names []string
+	for _, t := range f.Templating.List {
+		names = append(names, t.Name)
+	}
+	require.Equal(t, []string{"datasource", "job", "instance"}, names)
+	require.Equal(t, `sum(up{job=~"$job",instance=~"$instance"})`, f.Panels[0].Targets[0].Expr)
+
+	// Every fix is reported once, and nothing is left to fix.
+	require.Equal(t, lint.Fixed, res.Results.MaximumSeverity())
+	summary := res.Results.Summary()
+	require.Equal(t, 5, summary.FixesApplied)
+	require.Zero(t, summary.Total.Error)
+	require.Zero(t, summary.FixesAvailable)
+}
+
+func TestLinterDryRun(t *testing.T) {
+	config := lint.NewConfigurationFile()
+	config.Autofix = true
+	config.DryRun = true
+	linter, err := lint.NewLinter(
+		lint.WithRules(
+			lint.NewTemplateDatasourceRule(),
+			lint.NewTemplateJobRule(),
+			lint.NewTemplateInstanceRule(),
+			lint.NewTargetJobRule(),
+			lint.NewTargetInstanceRule(),
+		),
+		lint.WithConfig(config),
+	)
+	require.NoError(t, err)
+
+	res, err := linter.Lint([]byte(`{
+		"title": "test",
+		"panels": [ {
+			"id": 1, "type": "timeseries", "title": "up",
+			"datasource": { "type": "prometheus", "uid": "prom-1" },
+			"targets": [ { "refId": "A", "expr": "sum(up)" } ]
+		} ]
+	}`))
+	require.NoError(t, err)
+
+	// The fixed dashboard is computed, but the results are reported as pending fixes.
+	f, err := lint.NewDashboard(res.Fixed)
+	require.NoError(t, err)
+	require.Equal(t, `sum(up{job=~"$job",instance=~"$instance"})`, f.Panels[0].Targets[0].Expr)
+	require.Equal(t, lint.Error, res.Results.MaximumSeverity())
+	summary := res.Results.Summary()
+	require.Zero(t, summary.FixesApplied)
+	require.Equal(t, 5, summary.Total.Error)
+	require.Equal(t, 5, summary.FixesAvailable)
+}
+
+func TestLinterAutofixAddsMissingFields(t *testing.T) {
+	config := lint.NewConfigurationFile()
+	config.Autofix = true
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewTemplateOnTimeRangeReloadRule()),
+		lint.WithConfig(config),
+	)
+	require.NoError(t, err)
+
+	res, err := linter.Lint([]byte(`{
+  "title": "test",
+  "templating": {
+    "list": [
+      {
+        "name": "job",
+        "type": "query",
+        "query": "label_values(up, job)"
+      }
+    ]
+  }
+}`))
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "title": "test",
+  "templating": {
+    "list": [
+      {
+        "name": "job",
+        "type": "query",
+        "query": "label_values(up, job)",
+        "refresh": 2
+      }
+    ]
+  }
+}`, string(res.Fixed))
+}
+
+func TestLinterAutofixKubernetesDashboard(t *testing.T) {
+	config := lint.NewConfigurationFile()
+	config.Autofix = true
+	linter, err := lint.NewLinter(
+		lint.WithRules(lint.NewUneditableRule()),
+		lint.WithConfig(config),
+	)
+	require.NoError(t, err)
+
+	dashboard := `{
+  "apiVersion": "dashboard.grafana.app/v1beta1",
+  "kind": "Dashboard",
+  "metadata": { "name": "test", "labels": { "editable": "true" } },
+  "spec": {
+    "title": "test",
+    "editable": true
+  }
+}`
+	res, err := linter.Lint([]byte(dashboard))
+	require.NoError(t, err)
+	require.Equal(t, strings.Replace(dashboard, `"editable": true`, `"editable": false`, 1), string(res.Fixed))
+}
+
+func TestLinterCustomRulesFromConfig(t *testing.T) {
+	config := lint.NewConfigurationFile()
+	config.Rules = []lint.CustomRuleConfig{{
+		Name:       "has-tags",
+		Scope:      lint.CategoryDashboard,
+		Expression: `has(dashboard.tags)`,
+	}}
+	linter, err := lint.NewLinter(lint.WithRules(), lint.WithConfig(config))
+	require.NoError(t, err)
+	require.Len(t, linter.Rules(), 1)
+
+	config.Rules[0].Expression = `dashboard.tags`
+	_, err = lint.NewLinter(lint.WithConfig(config))
+	require.ErrorContains(t, err, "failed to load custom rules: custom rule 'has-tags' expression must evaluate to a bool")
+}
+
+func TestLinterErrors(t *testing.T) {
+	linter, err := lint.NewLinter()
+	require.NoError(t, err)
+	_, err = linter.Lint([]byte(`{`))
+	require.ErrorContains(t, err, "failed to parse dashboard")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	linter, err = lint.NewLinter(lint.WithContext(ctx))
+	require.NoError(t, err)
+	_, err = linter.Lint([]byte(linterDashboard))
+	require.EqualError(t, err, "failed to lint dashboard: context canceled")
+}
@@ -0,0 +1,119 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Category groups rules by the part of the dashboard they inspect.
+type Category string
+
+const (
+	CategoryDashboard Category = "dashboard"
+	CategoryTemplate  Category = "template"
+	CategoryPanel     Category = "panel"
+	CategoryTarget    Category = "target"
+)
+
+// docsBaseURL is where the documentation for the built-in rules is published.
+const docsBaseURL = "https://github.com/grafana/dashboard-linter/blob/main/docs/rules/"
+
+// Metadata describes a rule beyond its name and description. It is used to generate
+// documentation and to let reporters and tooling reason about rules without running them.
+type Metadata struct {
+	Category Category `json:"category"`
+	// Datasources lists the datasource plugin types the rule applies to. Empty means all.
+	Datasources []string `json:"datasources,omitempty"`
+	// Severity is the severity of the results the rule emits unless configured otherwise.
+	Severity Severity `json:"severity"`
+	// Fixable is true when the rule can fix at least some of its results with --fix.
+	Fixable bool `json:"fixable"`
+	// Weight is how much the rule counts towards the quality score of a dashboard, relative to
+	// other rules. It defaults to 1.
+	Weight    float64 `json:"weight"`
+	DocsURL   string  `json:"docsUrl,omitempty"`
+	Rationale string  `json:"rationale,omitempty"`
+}
+
+// RuleWithMetadata is an optional interface rules can implement to describe themselves.
+type RuleWithMetadata interface {
+	Rule
+	Metadata() Metadata
+}
+
+// GetMetadata returns the metadata of the rule, or sensible defaults if the rule does
+// not implement RuleWithMetadata.
+func GetMetadata(r Rule) Metadata {
+	m := Metadata{}
+	if mr, ok := r.(RuleWithMetadata); ok {
+		m = mr.Metadata()
+	}
+	if m.Category == "" {
+		m.Category = CategoryDashboard
+	}
+	if m.Severity == Success {
+		m.Severity = Error
+	}
+	if m.Weight <= 0 {
+		m.Weight = 1
+	}
+	return m
+}
+
+// docsURL returns the published documentation URL of a built-in rule.
+func docsURL(name string) string {
+	return docsBaseURL + name + ".md"
+}
+
+// RuleDoc is the documentation of a single rule, as printed by the rules command.
+type RuleDoc struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Metadata
+}
+
+// Docs returns the documentation of every rule in the RuleSet, in rule order.
+func (s *RuleSet) Docs() []RuleDoc {
+	docs := make([]RuleDoc, 0, len(s.rules))
+	for _, r := range s.rules {
+		docs = append(docs, RuleDoc{
+			Name:        r.Name(),
+			Description: r.Description(),
+			Metadata:    GetMetadata(r),
+		})
+	}
+	return docs
+}
+
+var severityNames = map[Severity]string{
+	Success: "success",
+	Exclude: "exclude",
+	Quiet:   "quiet",
+	Warning: "warning",
+	Error:   "error",
+	Fixed:   "fixed",
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	if _, ok := severityNames[s]; !ok {
+		return nil, fmt.Errorf("unknown severity %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	for sev, name := range severityNames {
+		if strings.EqualFold(name, string(text)) {
+			*s = sev
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity '%s'", text)
+}
@@ -0,0 +1,50 @@
+package lint
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinRuleMetadata(t *testing.T) {
+	rules := NewRuleSet()
+	for _, doc := range rules.Docs() {
+		t.Run(doc.Name, func(t *testing.T) {
+			require.NotEmpty(t, doc.Category)
+			require.NotEmpty(t, doc.Rationale)
+			require.True(t, strings.HasPrefix(doc.DocsURL, docsBaseURL))
+
+			// Every built-in rule must be documented in docs/rules.
+			_, err := os.Stat(path.Join("..", "docs", "rules", path.Base(doc.DocsURL)))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	t.Run("defaults for rules without metadata", func(t *testing.T) {
+		m := GetMetadata(&TestRule{name: "test"})
+		require.Equal(t, Metadata{Category: CategoryDashboard, Severity: Error, Weight: 1}, m)
+	})
+
+	t.Run("category defaults to the rule func type", func(t *testing.T) {
+		require.Equal(t, CategoryPanel, GetMetadata(NewPanelRuleFunc("test", "test", nil)).Category)
+		require.Equal(t, CategoryTarget, GetMetadata(NewTargetRuleFunc("test", "test", nil)).Category)
+		require.Equal(t, CategoryTemplate, GetMetadata(NewTemplateJobRule()).Category)
+	})
+}
+
+func TestSeverityText(t *testing.T) {
+	buf, err := json.Marshal(struct{ S Severity }{Warning})
+	require.NoError(t, err)
+	require.Equal(t, `{"S":"warning"}`, string(buf))
+
+	var s Severity
+	require.NoError(t, s.UnmarshalText([]byte("Error")))
+	require.Equal(t, Error, s)
+	require.Error(t, s.UnmarshalText([]byte("fatal")))
+}
@@ -140,10 +140,182 @@ func TestParseTemplate(t *testing.T) {
 			input:    []byte(`{ "type": "query", "query": {} }`),
 			expected: Template{Type: "query", RawQuery: map[string]interface{}{}},
 		},
+		{
+			// Group by variables have no query at all.
+			input: []byte(`{ "type": "groupby", "name": "groupby", "current": { "value": ["job"] }, "defaultValue": { "value": "instance" } }`),
+			expected: Template{Type: "groupby", Name: "groupby",
+				Current: RawTemplateValue{"value": []interface{}{"job"}}, DefaultValue: RawTemplateValue{"value": "instance"}},
+		},
 	} {
 		var actual Template
 		err := json.Unmarshal(tc.input, &actual)
 		require.NoError(t, err)
+		// What the template keeps of the JSON is tested by TestDashboardRoundTrip.
+		actual.fields = jsonFields{}
 		require.Equal(t, tc.expected, actual)
 	}
 }
+
+func TestDashboardRoundTrip(t *testing.T) {
+	sampleDashboard, err := os.ReadFile("testdata/dashboard.json")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "sample dashboard",
+			input: string(sampleDashboard),
+		},
+		{
+			name:  "empty",
+			input: `{}`,
+		},
+		{
+			name: "unknown fields",
+			input: `{
+				"uid": "abc", "version": 3, "style": "dark",
+				"__inputs": [ { "name": "DS", "type": "datasource", "pluginId": "prometheus", "pluginName": "Prometheus" } ],
+				"templating": { "enable": true, "list": [
+					{ "name": "job", "type": "query", "query": "label_values(up, job)", "hide": 0, "sort": 1, "regex": "/(.*)/" },
+					{ "name": "filters", "type": "adhoc", "datasource": { "uid": "$datasource" }, "filters": [ { "key": "job", "operator": "=", "value": "node", "condition": "" } ] },
+					{ "name": "groupby", "type": "groupby", "datasource": { "uid": "$datasource" }, "current": { "text": [], "value": [] }, "options": [], "allowCustomValue": true }
+				] },
+				"annotations": { "list": [ { "name": "Deploys", "enable": true, "iconColor": "red", "expr": "changes(x[5m])" } ] },
+				"rows": [ { "title": "legacy", "collapse": false, "height": "250px", "panels": [
+					{ "id": 1, "type": "graph", "title": "a", "span": 6, "lines": true,
+					  "targets": [ { "expr": "up", "legendFormat": "{{job}}", "intervalFactor": 2 } ] }
+				] } ],
+				"panels": [
+					{ "id": 2, "type": "timeseries", "title": "b", "gridPos": { "h": 8, "w": 12, "x": 0, "y": 0 },
+					  "fieldConfig": {
+						"defaults": { "unit": "s", "color": { "mode": "palette-classic" }, "custom": { "lineWidth": 1 } },
+						"overrides": [ { "matcher": { "id": "byName", "options": "x" }, "properties": [ { "id": "unit", "value": "ms" } ] } ]
+					  },
+					  "options": { "legend": { "showLegend": true } },
+					  "targets": [ { "refId": "A", "expr": "rate(x[5m])", "hide": false, "exemplar": true } ] },
+					{ "type": "row", "title": "nested", "collapsed": true, "panels": [ { "id": 3, "type": "stat", "title": "c" } ] }
+				]
+			}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewDashboard([]byte(tc.input))
+			require.NoError(t, err)
+			actual, err := d.Marshal()
+			require.NoError(t, err)
+			require.JSONEq(t, tc.input, string(actual))
+		})
+	}
+
+	t.Run("kubernetes spec", func(t *testing.T) {
+		spec := `{ "title": "k8s", "uid": "abc", "panels": [ { "id": 1, "type": "text", "title": "t", "options": { "content": "hi" } } ] }`
+		d, err := NewDashboard([]byte(`{ "apiVersion": "dashboard.grafana.app/v1beta1", "kind": "Dashboard", "metadata": { "name": "abc" }, "spec": ` + spec + ` }`))
+		require.NoError(t, err)
+		actual, err := d.Marshal()
+		require.NoError(t, err)
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal(actual, &m))
+		delete(m, "apiVersion")
+		delete(m, "spec")
+		actual, err = json.Marshal(m)
+		require.NoError(t, err)
+		require.JSONEq(t, spec, string(actual))
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		d, err := NewDashboard([]byte(`{
+			"title": "test",
+			"templating": { "list": [ { "name": "job", "type": "query", "query": "label_values(up, job)", "sort": 1 } ] },
+			"panels": [ { "id": 1, "type": "timeseries", "title": "a", "gridPos": { "h": 8 }, "targets": [ { "expr": "up", "interval": "1m" } ] } ]
+		}`))
+		require.NoError(t, err)
+		d.Templating.List[0].Refresh = 2
+		d.Templating.List = append(d.Templating.List, Template{Name: "instance", Type: "query", Query: "label_values(up, instance)", RawQuery: "label_values(up, instance)"})
+		d.Panels[0].Title = "b"
+		d.Panels[0].Targets[0].Expr = "sum(up)"
+		actual, err := d.Marshal()
+		require.NoError(t, err)
+		// Changed and added values are marshalled along with the fields the model does not know.
+		require.JSONEq(t, `{
+			"title": "test",
+			"templating": { "list": [
+				{ "name": "job", "type": "query", "query": "label_values(up, job)", "sort": 1, "refresh": 2 },
+				{ "name": "instance", "label": "", "type": "query", "query": "label_values(up, instance)", "multi": false, "current": null, "options": null, "refresh": 0 }
+			] },
+			"panels": [ { "id": 1, "type": "timeseries", "title": "b", "gridPos": { "h": 8 }, "targets": [ { "expr": "sum(up)", "interval": "1m" } ] } ]
+		}`, string(actual))
+	})
+}
+
+func TestParseDashboardFields(t *testing.T) {
+	input := `{
+		"uid": "abc", "title": "fields", "tags": [ "a", "b" ], "schemaVersion": 39,
+		"time": { "from": "now-6h", "to": "now" }, "refresh": "1m", "timezone": "browser", "graphTooltip": 1,
+		"links": [ { "title": "More", "type": "dashboards", "tags": [ "a" ], "asDropdown": true, "includeVars": true, "keepTime": true, "icon": "external link" } ],
+		"panels": [ {
+			"id": 1, "type": "timeseries", "title": "p",
+			"gridPos": { "h": 8, "w": 12, "x": 12, "y": 0 },
+			"repeat": "instance", "repeatDirection": "v", "maxPerRow": 4,
+			"maxDataPoints": 100, "interval": "30s", "timeFrom": "1h", "timeShift": "1w",
+			"transformations": [ { "id": "reduce", "disabled": true, "options": { "reducers": [ "max" ] } } ],
+			"libraryPanel": { "uid": "lib", "name": "Library" },
+			"links": [ { "title": "Details", "url": "/d/abc", "targetBlank": true } ],
+			"fieldConfig": { "defaults": { "thresholds": { "mode": "percentage", "steps": [ { "color": "green", "value": null }, { "color": "red", "value": 90 } ] } } },
+			"targets": [ { "refId": "A", "expr": "up", "legendFormat": "{{instance}}", "interval": "1m", "format": "table" } ]
+		} ]
+	}`
+	d, err := NewDashboard([]byte(input))
+	require.NoError(t, err)
+
+	assert.Equal(t, "abc", d.UID)
+	assert.Equal(t, []string{"a", "b"}, d.Tags)
+	assert.Equal(t, 39, d.SchemaVersion)
+	assert.Equal(t, "now-6h", d.Time.From)
+	assert.Equal(t, "now", d.Time.To)
+	assert.Equal(t, "1m", d.GetRefresh())
+	assert.Equal(t, "browser", d.Timezone)
+	assert.Equal(t, 1, d.GraphTooltip)
+	require.Len(t, d.Links, 1)
+	assert.Equal(t, "dashboards", d.Links[0].Type)
+	assert.Equal(t, []string{"a"}, d.Links[0].Tags)
+	assert.True(t, d.Links[0].AsDropdown && d.Links[0].IncludeVars && d.Links[0].KeepTime)
+
+	p := d.Panels[0]
+	assert.Equal(t, 12, p.GridPos.X)
+	assert.Equal(t, 8, p.GridPos.H)
+	assert.Equal(t, "instance", p.Repeat)
+	assert.Equal(t, "v", p.RepeatDirection)
+	assert.Equal(t, 100, p.MaxDataPoints)
+	assert.Equal(t, "30s", p.Interval)
+	assert.Equal(t, "1h", p.TimeFrom)
+	assert.Equal(t, "1w", p.TimeShift)
+	require.Len(t, p.Transformations, 1)
+	assert.Equal(t, "reduce", p.Transformations[0].Id)
+	assert.True(t, p.Transformations[0].Disabled)
+	assert.Equal(t, "lib", p.LibraryPanel.UID)
+	assert.Equal(t, "Library", p.LibraryPanel.Name)
+	require.Len(t, p.Links, 1)
+	assert.Equal(t, "/d/abc", p.Links[0].URL)
+	assert.True(t, p.Links[0].TargetBlank)
+	assert.Equal(t, "percentage", p.FieldConfig.Defaults.Thresholds.Mode)
+	assert.Equal(t, 90.0, *p.FieldConfig.Defaults.Thresholds.Steps[1].Value)
+	assert.Equal(t, "{{instance}}", p.Targets[0].LegendFormat)
+	assert.Equal(t, "1m", p.Targets[0].Interval)
+	assert.Equal(t, "table", p.Targets[0].Format)
+
+	actual, err := d.Marshal()
+	require.NoError(t, err)
+	require.JSONEq(t, input, string(actual))
+
+	t.Run("refresh disabled", func(t *testing.T) {
+		input := `{ "title": "no refresh", "refresh": false }`
+		d, err := NewDashboard([]byte(input))
+		require.NoError(t, err)
+		assert.Equal(t, "", d.GetRefresh())
+		actual, err := d.Marshal()
+		require.NoError(t, err)
+		require.JSONEq(t, input, string(actual))
+	})
+}
@@ -0,0 +1,238 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// PluginProtocolVersion is the version of the JSON protocol spoken with rule plugins. It is sent
+// with every request, and plugins must echo it back in their response.
+const PluginProtocolVersion = 1
+
+const defaultPluginTimeout = 30 * time.Second
+
+// PluginConfig declares an external rule executable in the configuration file.
+type PluginConfig struct {
+	Name        string        `yaml:"name"`
+	Description string        `yaml:"description"`
+	Command     string        `yaml:"command"`
+	Args        []string      `yaml:"args"`
+	Timeout     time.Duration `yaml:"timeout"`
+}
+
+// PluginRequest is written as JSON to the plugin's stdin, once per dashboard.
+type PluginRequest struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	Rule            string `json:"rule"`
+	// Dashboard is the dashboard JSON, which fixes are applied to.
+	Dashboard map[string]interface{} `json:"dashboard"`
+	// Panels are the parsed panels of the dashboard, including those nested in rows, in the
+	// order PluginLocation refers to them.
+	Panels []Panel `json:"panels"`
+}
+
+// PluginResponse is read as JSON from the plugin's stdout.
+type PluginResponse struct {
+	ProtocolVersion int            `json:"protocolVersion"`
+	Results         []PluginResult `json:"results"`
+}
+
+// PluginResult is a single finding reported by a plugin.
+type PluginResult struct {
+	// Severity is success, warning or error, which is the default if it is missing.
+	Severity *Severity `json:"severity,omitempty"`
+	Message  string    `json:"message"`
+	// Location is nil for findings about the whole dashboard.
+	Location *PluginLocation `json:"location,omitempty"`
+	// Fix is an optional JSON Patch which fixes the finding when applied to the dashboard JSON.
+	Fix []PatchOperation `json:"fix,omitempty"`
+}
+
+// PluginLocation points to a panel, and optionally one of its targets, by index.
+type PluginLocation struct {
+	Panel  int  `json:"panel"`
+	Target *int `json:"target,omitempty"`
+}
+
+type pluginRule struct {
+	config PluginConfig
+}
+
+// NewPluginRule wraps an external rule executable as a Rule.
+func NewPluginRule(c PluginConfig) (Rule, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("plugin is missing a name")
+	}
+	if c.Command == "" {
+		return nil, fmt.Errorf("plugin '%s' is missing a command", c.Name)
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultPluginTimeout
+	}
+	return &pluginRule{config: c}, nil
+}
+
+func (r *pluginRule) Name() string        { return r.config.Name }
+func (r *pluginRule) Description() string { return r.config.Description }
+
+func (r *pluginRule) Lint(d Dashboard, s *ResultSet) {
+	r.LintContext(context.Background(), d, s)
+}
+
+func (r *pluginRule) LintContext(ctx context.Context, d Dashboard, s *ResultSet) {
+	panels := d.GetPanels()
+	res, err := r.run(ctx, PluginRequest{
+		ProtocolVersion: PluginProtocolVersion,
+		Rule:            r.config.Name,
+		Dashboard:       rawDashboard(d),
+		Panels:          panels,
+	})
+	if err != nil {
+		r.addResult(s, d, nil, nil, FixableResult{Result: Result{
+			Severity: Error,
+			Message:  dashboardMessage(d, fmt.Sprintf("could not be linted by plugin '%s': %v", r.config.Name, err)),
+		}})
+		return
+	}
+
+	if len(res.Results) == 0 {
+		r.addResult(s, d, nil, nil, FixableResult{Result: ResultSuccess})
+		return
+	}
+	for _, pr := range res.Results {
+		pr := pr // capture loop variable
+		result := FixableResult{Result: Result{Severity: Error, Message: pr.Message}}
+		if pr.Severity != nil {
+			switch *pr.Severity {
+			case Success, Warning, Error:
+				result.Severity = *pr.Severity
+			default:
+				// The other severities are given by the linter, depending on the configuration.
+				r.addResult(s, d, nil, nil, FixableResult{Result: Result{
+					Severity: Error,
+					Message:  dashboardMessage(d, fmt.Sprintf("plugin '%s' reported an invalid severity '%s'", r.config.Name, *pr.Severity)),
+				}})
+				continue
+			}
+		}
+		if len(pr.Fix) > 0 && result.Severity != Success {
+			// A patch which does not apply to the linted dashboard is reported instead of offered
+			// as a fix.
+			patched := d
+			if err := patchDashboard(&patched, pr.Fix); err != nil {
+				result.Message += fmt.Sprintf(" (the fix does not apply: %v)", err)
+			} else {
+				result.Fix = func(d *Dashboard) {
+					// If an earlier fix made the patch fail, the dashboard is left untouched, and
+					// the result is reported again when the fixed dashboard is linted.
+					_ = patchDashboard(d, pr.Fix)
+				}
+			}
+		}
+
+		if pr.Location == nil {
+			result.Message = dashboardMessage(d, result.Message)
+			r.addResult(s, d, nil, nil, result)
+			continue
+		}
+		if pr.Location.Panel < 0 || pr.Location.Panel >= len(panels) {
+			r.addResult(s, d, nil, nil, FixableResult{Result: Result{
+				Severity: Error,
+				Message:  dashboardMessage(d, fmt.Sprintf("plugin '%s' reported an invalid panel index %d", r.config.Name, pr.Location.Panel)),
+			}})
+			continue
+		}
+		p := panels[pr.Location.Panel]
+		if pr.Location.Target == nil {
+			result.Message = panelMessage(d, p, result.Message)
+			r.addResult(s, d, &p, nil, result)
+			continue
+		}
+		ti := *pr.Location.Target
+		if ti < 0 || ti >= len(p.Targets) {
+			r.addResult(s, d, &p, nil, FixableResult{Result: Result{
+				Severity: Error,
+				Message:  panelMessage(d, p, fmt.Sprintf("plugin '%s' reported an invalid target index %d", r.config.Name, ti)),
+			}})
+			continue
+		}
+		t := p.Targets[ti]
+		result.Message = targetMessage(d, p, t, result.Message)
+		r.addResult(s, d, &p, &t, result)
+	}
+}
+
+func (r *pluginRule) addResult(s *ResultSet, d Dashboard, p *Panel, t *Target, result FixableResult) {
+	s.AddResult(ResultContext{
+		Result:    RuleResults{[]FixableResult{result}},
+		Rule:      r,
+		Dashboard: &d,
+		Panel:     p,
+		Target:    t,
+	})
+}
+
+// run executes the plugin with the request on stdin, and decodes the response from stdout.
+func (r *pluginRule) run(ctx context.Context, req PluginRequest) (PluginResponse, error) {
+	var res PluginResponse
+	in, err := json.Marshal(req)
+	if err != nil {
+		return res, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, r.config.Command, r.config.Args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return res, fmt.Errorf("%w: %s", err, msg)
+		}
+		return res, err
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		return res, fmt.Errorf("invalid response: %w", err)
+	}
+	if res.ProtocolVersion != PluginProtocolVersion {
+		return res, fmt.Errorf("unsupported protocol version %d, expected %d", res.ProtocolVersion, PluginProtocolVersion)
+	}
+	return res, nil
+}
+
+// patchDashboard applies a JSON Patch to the JSON representation of the dashboard.
+func patchDashboard(d *Dashboard, ops []PatchOperation) error {
+	buf, err := d.Marshal()
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return err
+	}
+	if doc, err = applyPatch(doc, ops); err != nil {
+		return err
+	}
+	if buf, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	var patched Dashboard
+	if err := json.Unmarshal(buf, &patched); err != nil {
+		return err
+	}
+	patched.Source, patched.raw, patched.v2, patched.library = d.Source, d.raw, d.v2, d.library
+	if d.libraryPanels != nil {
+		// Changes to the library panels are dropped, as they are not part of the dashboard.
+		patched.ResolveLibraryPanels(d.libraryPanels)
+	}
+	*d = patched
+	return nil
+}
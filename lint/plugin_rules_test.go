@@ -0,0 +1,187 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const fakePluginEnv = "DASHBOARD_LINTER_FAKE_PLUGIN"
+
+// TestFakePlugin is not a real test. It is executed as a subprocess by the plugin tests, and
+// behaves like a rule plugin according to the mode set in the environment.
+func TestFakePlugin(t *testing.T) {
+	mode := os.Getenv(fakePluginEnv)
+	if mode == "" {
+		return
+	}
+
+	var req PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid request: %v", err)
+		os.Exit(2)
+	}
+
+	res := PluginResponse{ProtocolVersion: req.ProtocolVersion}
+	target := 0
+	switch mode {
+	case "ok":
+	case "findings":
+		res.Results = []PluginResult{
+			{
+				Severity: severity(Warning),
+				Message:  fmt.Sprintf("has %d panels and title '%s'", len(req.Panels), req.Dashboard["title"]),
+			},
+			{
+				Message:  "has a bad title",
+				Location: &PluginLocation{Panel: 1},
+				Fix:      []PatchOperation{{Op: "replace", Path: "/rows/0/panels/0/panels/0/title", Value: "fixed"}},
+			},
+			{
+				Severity: severity(Error),
+				Message:  fmt.Sprintf("uses metric '%s'", req.Panels[2].Targets[0].Expr),
+				Location: &PluginLocation{Panel: 2, Target: &target},
+			},
+		}
+	case "bad-fix":
+		res.Results = []PluginResult{{
+			Message: "has a bad title",
+			Fix:     []PatchOperation{{Op: "replace", Path: "/panels/7/title", Value: "fixed"}},
+		}}
+	case "severities":
+		res.Results = []PluginResult{
+			{Severity: severity(Success), Message: "has been checked"},
+			{Message: "has no severity"},
+		}
+	case "invalid-severity":
+		res.Results = []PluginResult{{Severity: severity(Fixed), Message: "has been fixed"}}
+	case "invalid-location":
+		res.Results = []PluginResult{{Message: "nope", Location: &PluginLocation{Panel: 7}}}
+	case "version":
+		res.ProtocolVersion = PluginProtocolVersion + 1
+	case "fail":
+		fmt.Fprint(os.Stderr, "catalogue unavailable")
+		os.Exit(1)
+	}
+	_ = json.NewEncoder(os.Stdout).Encode(res)
+	os.Exit(0)
+}
+
+func severity(s Severity) *Severity {
+	return &s
+}
+
+const pluginDashboard = `{
+	"title": "test",
+	"rows": [
+		{ "panels": [ { "id": 1, "type": "row", "title": "row", "panels": [ { "id": 2, "type": "stat", "title": "nested" } ] } ] }
+	],
+	"panels": [
+		{ "id": 3, "type": "timeseries", "title": "cpu", "targets": [ { "refId": "A", "expr": "node_cpu_seconds_total" } ] }
+	]
+}`
+
+func TestPluginRule(t *testing.T) {
+	for _, tc := range []struct {
+		mode     string
+		expected []Result
+	}{
+		{
+			mode:     "ok",
+			expected: []Result{ResultSuccess},
+		},
+		{
+			mode: "findings",
+			expected: []Result{
+				{Severity: Warning, Message: "Dashboard 'test' has 3 panels and title 'test'"},
+				{Severity: Error, Message: "Dashboard 'test', panel 'nested' has a bad title"},
+				{Severity: Error, Message: "Dashboard 'test', panel 'cpu', target idx '0' uses metric 'node_cpu_seconds_total'"},
+			},
+		},
+		{
+			// An explicit success passes, a missing severity is an error.
+			mode: "severities",
+			expected: []Result{
+				{Severity: Success, Message: "Dashboard 'test' has been checked"},
+				{Severity: Error, Message: "Dashboard 'test' has no severity"},
+			},
+		},
+		{
+			mode:     "invalid-severity",
+			expected: []Result{{Severity: Error, Message: "Dashboard 'test' plugin 'fake' reported an invalid severity 'fixed'"}},
+		},
+		{
+			mode:     "invalid-location",
+			expected: []Result{{Severity: Error, Message: "Dashboard 'test' plugin 'fake' reported an invalid panel index 7"}},
+		},
+		{
+			mode:     "version",
+			expected: []Result{{Severity: Error, Message: "Dashboard 'test' could not be linted by plugin 'fake': unsupported protocol version 2, expected 1"}},
+		},
+		{
+			mode:     "fail",
+			expected: []Result{{Severity: Error, Message: "Dashboard 'test' could not be linted by plugin 'fake': exit status 1: catalogue unavailable"}},
+		},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			t.Setenv(fakePluginEnv, tc.mode)
+			rule, err := NewPluginRule(PluginConfig{
+				Name:    "fake",
+				Command: os.Args[0],
+				Args:    []string{"-test.run=^TestFakePlugin$"},
+			})
+			require.NoError(t, err)
+
+			d, err := NewDashboard([]byte(pluginDashboard))
+			require.NoError(t, err)
+			rs := ResultSet{}
+			rule.Lint(d, &rs)
+
+			var actual []Result
+			for _, rc := range rs.results {
+				for _, r := range rc.Result.Results {
+					actual = append(actual, r.Result)
+				}
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("fix", func(t *testing.T) {
+		t.Setenv(fakePluginEnv, "findings")
+		rule, err := NewPluginRule(PluginConfig{Name: "fake", Command: os.Args[0], Args: []string{"-test.run=^TestFakePlugin$"}})
+		require.NoError(t, err)
+
+		d, err := NewDashboard([]byte(pluginDashboard))
+		require.NoError(t, err)
+		rs := ResultSet{}
+		rule.Lint(d, &rs)
+		require.Equal(t, 1, rs.AutoFix(&d))
+		require.Equal(t, "fixed", d.GetPanels()[1].Title)
+	})
+
+	t.Run("fix does not apply", func(t *testing.T) {
+		t.Setenv(fakePluginEnv, "bad-fix")
+		rule, err := NewPluginRule(PluginConfig{Name: "fake", Command: os.Args[0], Args: []string{"-test.run=^TestFakePlugin$"}})
+		require.NoError(t, err)
+
+		d, err := NewDashboard([]byte(pluginDashboard))
+		require.NoError(t, err)
+		rs := ResultSet{}
+		rule.Lint(d, &rs)
+		require.Len(t, rs.results, 1)
+		r := rs.results[0].Result.Results[0]
+		require.Nil(t, r.Fix)
+		require.Equal(t, Error, r.Severity)
+		require.Equal(t, "Dashboard 'test' has a bad title (the fix does not apply: json patch replace '/panels/7/title': invalid array index '7')", r.Message)
+		require.Zero(t, rs.AutoFix(&d))
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewPluginRule(PluginConfig{Name: "fake"})
+		require.EqualError(t, err, "plugin 'fake' is missing a command")
+	})
+}
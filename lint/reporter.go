@@ -0,0 +1,262 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/term"
+)
+
+// Reporter writes the results of a lint run to w in some format.
+type Reporter interface {
+	Report(w io.Writer, rs *ResultSet) error
+}
+
+// streamingReporter is implemented by reporters which can write the results of each dashboard as
+// it is linted. The others write a single document, so a Linter reports to them on Close.
+type streamingReporter interface {
+	Reporter
+	streams()
+}
+
+var reporters = map[string]func() Reporter{
+	"tty":        func() Reporter { return TTYReporter{} },
+	"json":       func() Reporter { return JSONReporter{} },
+	"sarif":      func() Reporter { return SARIFReporter{} },
+	"html":       func() Reporter { return HTMLReporter{} },
+	"prometheus": func() Reporter { return PrometheusReporter{} },
+}
+
+// ReporterFormats returns the names of the formats NewReporter accepts.
+func ReporterFormats() []string {
+	formats := make([]string, 0, len(reporters))
+	for f := range reporters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// NewReporter returns the reporter for the named format.
+func NewReporter(format string) (Reporter, error) {
+	newReporter, ok := reporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format '%s', must be one of %s", format, strings.Join(ReporterFormats(), ", "))
+	}
+	return newReporter(), nil
+}
+
+// GroupBy selects how the TTY reporter groups results.
+type GroupBy string
+
+const (
+	GroupByRule      GroupBy = "rule"
+	GroupByDashboard GroupBy = "dashboard"
+	GroupByPanel     GroupBy = "panel"
+)
+
+// ParseGroupBy validates the name of a grouping.
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch g := GroupBy(s); g {
+	case GroupByRule, GroupByDashboard, GroupByPanel:
+		return g, nil
+	}
+	return "", fmt.Errorf("unknown grouping '%s', must be one of rule, dashboard or panel", s)
+}
+
+// TTYReporter writes human readable results, grouped as configured, and optionally a summary
+// table. Severities are colored when writing to a terminal.
+type TTYReporter struct{}
+
+func (TTYReporter) streams() {}
+
+func (TTYReporter) Report(w io.Writer, rs *ResultSet) error {
+	t := newTTYWriter(w, rs)
+
+	groupBy := GroupByRule
+	if rs.config != nil && rs.config.GroupBy != "" {
+		groupBy = rs.config.GroupBy
+	}
+	switch groupBy {
+	case GroupByDashboard:
+		t.byDashboard(rs)
+	case GroupByPanel:
+		t.byPanel(rs)
+	default:
+		t.byRule(rs)
+	}
+	if rs.config != nil && rs.config.Summary {
+		t.summary(rs.Summary())
+	}
+	return t.err
+}
+
+// ttyWriter keeps the first write error, so the report does not need to check every write.
+type ttyWriter struct {
+	w                     io.Writer
+	color, verbose, hints bool
+	err                   error
+}
+
+func newTTYWriter(w io.Writer, rs *ResultSet) *ttyWriter {
+	t := &ttyWriter{w: w, color: isTerminal(w)}
+	if rs.config != nil {
+		t.verbose = rs.config.Verbose
+		t.hints = rs.config.Hints
+	}
+	return t
+}
+
+func (t *ttyWriter) printf(format string, args ...interface{}) {
+	if t.err == nil {
+		_, t.err = fmt.Fprintf(t.w, format, args...)
+	}
+}
+
+// results prints the results, and returns whether any of them is a warning or an error.
+func (t *ttyWriter) results(rcs []ResultContext, indent string, withRule bool) bool {
+	failed := false
+	for _, rc := range rcs {
+		for _, r := range rc.Result.Results {
+			if r.Severity == Quiet || (r.Severity == Exclude && !t.verbose) {
+				continue
+			}
+			if r.Severity == Warning || r.Severity == Error {
+				failed = true
+			}
+			if withRule {
+				r.Message = fmt.Sprintf("%s (%s)", r.Message, rc.Rule.Name())
+			}
+			if t.err == nil {
+				_, t.err = io.WriteString(t.w, indent)
+			}
+			if t.err == nil {
+				t.err = r.ttyFprint(t.w, t.color)
+			}
+		}
+	}
+	return failed
+}
+
+func (t *ttyWriter) byRule(rs *ResultSet) {
+	byRule := rs.ByRule()
+	rules := make([]string, 0, len(byRule))
+	for r := range byRule {
+		rules = append(rules, r)
+	}
+	sort.Strings(rules)
+
+	for _, rule := range rules {
+		t.printf("%s\n", byRule[rule][0].Rule.Description())
+		if t.results(byRule[rule], "", false) && t.hints {
+			t.printf("%s\n", ExplainHint(rule))
+		}
+	}
+}
+
+func (t *ttyWriter) byDashboard(rs *ResultSet) {
+	byDashboard := rs.ByDashboard()
+	dashboards := make([]string, 0, len(byDashboard))
+	for d := range byDashboard {
+		dashboards = append(dashboards, d)
+	}
+	sort.Strings(dashboards)
+
+	for _, dashboard := range dashboards {
+		rcs := byDashboard[dashboard]
+		title := ""
+		if rcs[0].Dashboard != nil {
+			title = rcs[0].Dashboard.Title
+		}
+		t.printf("Dashboard '%s'%s\n", title, keyNote(title, dashboard))
+		for start := 0; start < len(rcs); {
+			key := panelKeyOf(rcs[start])
+			end := start + 1
+			for end < len(rcs) && panelKeyOf(rcs[end]) == key {
+				end++
+			}
+			indent := "  "
+			if key.HasPanel {
+				t.printf("  %s\n", panelHeading(key))
+				indent = "    "
+			}
+			t.results(rcs[start:end], indent, true)
+			start = end
+		}
+	}
+}
+
+func (t *ttyWriter) byPanel(rs *ResultSet) {
+	byPanel := rs.ByPanel()
+	keys := make([]PanelKey, 0, len(byPanel))
+	for k := range byPanel {
+		keys = append(keys, k)
+	}
+	SortPanelKeys(keys)
+
+	for _, key := range keys {
+		note := keyNote(key.DashboardTitle, key.Dashboard)
+		if key.HasPanel {
+			t.printf("Dashboard '%s'%s, %s\n", key.DashboardTitle, note, panelHeading(key))
+		} else {
+			t.printf("Dashboard '%s'%s\n", key.DashboardTitle, note)
+		}
+		t.results(byPanel[key], "  ", true)
+	}
+}
+
+// keyNote notes the key a dashboard is told apart by, see dashboardKey, unless it is the title.
+func keyNote(title, key string) string {
+	if key == title {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", key)
+}
+
+func panelHeading(k PanelKey) string {
+	if k.Panel == "" {
+		return fmt.Sprintf("panel with id '%d'", k.PanelID)
+	}
+	return fmt.Sprintf("panel '%s'", k.Panel)
+}
+
+func (t *ttyWriter) summary(sum Summary) {
+	tw := tabwriter.NewWriter(t.w, 0, 0, 2, ' ', 0)
+	fprintf := func(format string, args ...interface{}) {
+		if t.err == nil {
+			_, t.err = fmt.Fprintf(tw, format, args...)
+		}
+	}
+	counts := func(c Counts) string {
+		return fmt.Sprintf("%d\t%d\t%d\t%d\t%d", c.Error, c.Warning, c.Fixed, c.Excluded, c.OK)
+	}
+
+	t.printf("\nSummary\n")
+	fprintf("Rule\tErrors\tWarnings\tFixed\tExcluded\tOK\n")
+	for _, name := range sortedKeys(sum.Rules) {
+		fprintf("%s\t%s\n", name, counts(sum.Rules[name]))
+	}
+	fprintf("Dashboard\tErrors\tWarnings\tFixed\tExcluded\tOK\tScore\n")
+	for _, key := range sortedKeys(sum.Dashboards) {
+		// Dashboards are told apart by where they were read from, as titles need not be unique.
+		name := sum.Titles[key] + keyNote(sum.Titles[key], key)
+		if sum.Titles[key] == "" {
+			name = key
+		}
+		fprintf("%s\t%s\t%.1f\n", name, counts(sum.Dashboards[key]), sum.Scores[key])
+	}
+	fprintf("Total\t%s\n", counts(sum.Total))
+	if t.err == nil {
+		t.err = tw.Flush()
+	}
+	t.printf("Fixes applied: %d, available: %d\n", sum.FixesApplied, sum.FixesAvailable)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
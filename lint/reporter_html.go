@@ -0,0 +1,99 @@
+package lint
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+)
+
+//go:embed reporter_html.tmpl
+var htmlTemplateText string
+
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateText))
+
+// HTMLReporter writes a single self-contained HTML page, which works offline. It has a sortable
+// and filterable table of the results, highlights the problem in queries where it is known, and
+// shows the JSON of each panel.
+type HTMLReporter struct{}
+
+type htmlReport struct {
+	Summary    Summary
+	Rules      []string
+	Severities []string
+	Dashboards []string
+	Results    []htmlResult
+}
+
+type htmlResult struct {
+	Rule      string
+	Severity  string
+	Message   string
+	Dashboard string
+	Panel     string
+	Target    string
+	// The query of the target, split around the problem if its range is known.
+	QueryBefore, QueryProblem, QueryAfter string
+	PanelJSON                             string
+}
+
+func (HTMLReporter) Report(w io.Writer, rs *ResultSet) error {
+	report := htmlReport{Summary: rs.Summary()}
+	rules, severities, dashboards := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, rc := range rs.results {
+		for _, r := range rc.Result.Results {
+			if r.Severity == Quiet {
+				continue
+			}
+			hr := htmlResult{
+				Rule:     rc.Rule.Name(),
+				Severity: r.Severity.String(),
+				Message:  r.Message,
+			}
+			if rc.Dashboard != nil {
+				hr.Dashboard = rc.Dashboard.Title
+			}
+			if rc.Panel != nil {
+				hr.Panel = rc.Panel.Title
+				if hr.Panel == "" {
+					hr.Panel = fmt.Sprintf("id %d", rc.Panel.Id)
+				}
+				buf, err := json.MarshalIndent(rc.Panel, "", "  ")
+				if err != nil {
+					return err
+				}
+				hr.PanelJSON = string(buf)
+			}
+			if rc.Target != nil {
+				hr.Target = fmt.Sprintf("%d", rc.Target.Idx)
+				hr.QueryBefore, hr.QueryProblem, hr.QueryAfter = splitQuery(rc.Target.Expr, r.Range)
+			}
+			rules[hr.Rule], severities[hr.Severity], dashboards[hr.Dashboard] = true, true, true
+			report.Results = append(report.Results, hr)
+		}
+	}
+	report.Rules = sortedKeys(rules)
+	report.Severities = sortedKeys(severities)
+	report.Dashboards = sortedKeys(dashboards)
+	return htmlTemplate.Execute(w, report)
+}
+
+// splitQuery splits the query around the range, or returns the whole query first if the range
+// is unknown or does not fit the query.
+func splitQuery(query string, rng *QueryRange) (before, problem, after string) {
+	if rng == nil || rng.Start < 0 || rng.End > len(query) || rng.Start > rng.End {
+		return query, "", ""
+	}
+	return query[:rng.Start], query[rng.Start:rng.End], query[rng.End:]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,71 @@
+package lint
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONReporter writes the results as a single JSON document, for other tools to consume.
+// Every result is included except quiet ones, so consumers can filter by severity.
+type JSONReporter struct{}
+
+type jsonReport struct {
+	Results []jsonResult `json:"results"`
+	Summary Summary      `json:"summary"`
+}
+
+type jsonResult struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Message   string   `json:"message"`
+	Source    string   `json:"source,omitempty"`
+	Dashboard string   `json:"dashboard"`
+	Panel     string   `json:"panel,omitempty"`
+	PanelID   *int     `json:"panelId,omitempty"`
+	TargetIdx *int     `json:"targetIdx,omitempty"`
+	// LibraryPanel is the uid of the library panel the result is about, if any, and
+	// LibraryPanelSource where it was read from.
+	LibraryPanel       string `json:"libraryPanel,omitempty"`
+	LibraryPanelSource string `json:"libraryPanelSource,omitempty"`
+	// Range locates the problem in the target's expression, if known.
+	Range   *QueryRange `json:"range,omitempty"`
+	Fixable bool        `json:"fixable"`
+}
+
+func (JSONReporter) Report(w io.Writer, rs *ResultSet) error {
+	report := jsonReport{Results: []jsonResult{}, Summary: rs.Summary()}
+	for _, rc := range rs.results {
+		for _, r := range rc.Result.Results {
+			if r.Severity == Quiet {
+				continue
+			}
+			jr := jsonResult{
+				Rule:     rc.Rule.Name(),
+				Severity: r.Severity,
+				Message:  r.Message,
+				Range:    r.Range,
+				Fixable:  r.Fix != nil,
+			}
+			if rc.Dashboard != nil {
+				jr.Source = rc.Dashboard.Source
+				jr.Dashboard = rc.Dashboard.Title
+			}
+			if rc.Panel != nil {
+				jr.Panel = rc.Panel.Title
+				jr.PanelID = &rc.Panel.Id
+			}
+			if rc.Target != nil {
+				jr.TargetIdx = &rc.Target.Idx
+			}
+			if rc.LibraryPanel != nil {
+				jr.LibraryPanel = rc.LibraryPanel.UID
+				jr.LibraryPanelSource = rc.LibraryPanel.Source
+			}
+			report.Results = append(report.Results, jr)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,63 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PrometheusReporter writes result counts and dashboard quality scores as OpenMetrics text,
+// e.g. for the textfile collector of node_exporter, so lint health can be tracked over time.
+type PrometheusReporter struct{}
+
+// prometheusSeverities are the severities counted in dashboard_lint_findings. Every one of them
+// is written for every dashboard and rule, so series do not disappear when a count drops to 0.
+var prometheusSeverities = []Severity{Error, Warning, Fixed, Exclude}
+
+func (PrometheusReporter) Report(w io.Writer, rs *ResultSet) error {
+	type findingKey struct {
+		dashboard, rule string
+	}
+	findings := map[findingKey]*Counts{}
+	var keys []findingKey
+	for _, rc := range rs.results {
+		k := findingKey{dashboard: dashboardKey(rc.Dashboard), rule: rc.Rule.Name()}
+		if findings[k] == nil {
+			findings[k] = &Counts{}
+			keys = append(keys, k)
+		}
+		for _, r := range rc.Result.Results {
+			findings[k].add(r.Severity)
+		}
+	}
+
+	// Series are told apart by the source label, as titles need not be unique.
+	sum := rs.Summary()
+	var sb strings.Builder
+	sb.WriteString("# HELP dashboard_lint_findings Number of lint results by dashboard, rule and severity.\n")
+	sb.WriteString("# TYPE dashboard_lint_findings gauge\n")
+	for _, k := range keys {
+		c := findings[k]
+		for _, s := range prometheusSeverities {
+			n := map[Severity]int{Error: c.Error, Warning: c.Warning, Fixed: c.Fixed, Exclude: c.Excluded}[s]
+			fmt.Fprintf(&sb, "dashboard_lint_findings{dashboard=\"%s\",source=\"%s\",rule=\"%s\",severity=\"%s\"} %d\n",
+				escapeLabelValue(sum.Titles[k.dashboard]), escapeLabelValue(k.dashboard), escapeLabelValue(k.rule), s, n)
+		}
+	}
+
+	sb.WriteString("# HELP dashboard_lint_score Quality score of the dashboard from 0 to 100, based on weighted rule results.\n")
+	sb.WriteString("# TYPE dashboard_lint_score gauge\n")
+	for _, dashboard := range sortedKeys(sum.Scores) {
+		fmt.Fprintf(&sb, "dashboard_lint_score{dashboard=\"%s\",source=\"%s\"} %s\n",
+			escapeLabelValue(sum.Titles[dashboard]), escapeLabelValue(dashboard), strconv.FormatFloat(sum.Scores[dashboard], 'f', -1, 64))
+	}
+	sb.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
@@ -0,0 +1,168 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIFReporter writes warnings and errors as a SARIF 2.1.0 log, which code scanning tools
+// such as GitHub's can display next to the dashboard files.
+type SARIFReporter struct{}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	HelpURI              string            `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func sarifLevel(s Severity) string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+func (SARIFReporter) Report(w io.Writer, rs *ResultSet) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "dashboard-linter",
+			InformationURI: "https://github.com/grafana/dashboard-linter",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	rules := map[string]Rule{}
+	for _, rc := range rs.results {
+		for _, r := range rc.Result.Results {
+			if r.Severity != Warning && r.Severity != Error {
+				continue
+			}
+			rules[rc.Rule.Name()] = rc.Rule
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    rc.Rule.Name(),
+				Level:     sarifLevel(r.Severity),
+				Message:   sarifMessage{Text: r.Message},
+				Locations: sarifLocationsOf(rc),
+			})
+		}
+	}
+
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m := GetMetadata(rules[name])
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   name,
+			ShortDescription:     sarifMessage{Text: rules[name].Description()},
+			HelpURI:              m.DocsURL,
+			DefaultConfiguration: sarifRuleDefaults{Level: sarifLevel(m.Severity)},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// sarifLocationsOf locates a result in the dashboard file, and in the library panel file if the
+// result is about a library panel the dashboard uses.
+func sarifLocationsOf(rc ResultContext) []sarifLocation {
+	locs := []sarifLocation{sarifLocationOf(rc)}
+	lp := rc.LibraryPanel
+	if lp != nil && lp.Source != "" && (rc.Dashboard == nil || lp.Source != rc.Dashboard.Source) {
+		locs = append(locs, sarifLocation{
+			PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: lp.Source}},
+			LogicalLocations: []sarifLogicalLocation{{Name: lp.Name, FullyQualifiedName: lp.Name, Kind: "object"}},
+		})
+	}
+	return locs
+}
+
+// sarifLocationOf locates a result in the dashboard file, if known, and names the dashboard,
+// panel and target it applies to.
+func sarifLocationOf(rc ResultContext) sarifLocation {
+	var loc sarifLocation
+	if rc.Dashboard == nil {
+		return loc
+	}
+	if rc.Dashboard.Source != "" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: rc.Dashboard.Source}}
+	}
+	logical := sarifLogicalLocation{Name: rc.Dashboard.Title, FullyQualifiedName: rc.Dashboard.Title, Kind: "object"}
+	if rc.Panel != nil {
+		logical = sarifLogicalLocation{Name: rc.Panel.Title, FullyQualifiedName: fmt.Sprintf("%s/%s", logical.FullyQualifiedName, rc.Panel.Title), Kind: "object"}
+	}
+	if rc.Target != nil {
+		name := fmt.Sprintf("target[%d]", rc.Target.Idx)
+		logical = sarifLogicalLocation{Name: name, FullyQualifiedName: fmt.Sprintf("%s/%s", logical.FullyQualifiedName, name), Kind: "object"}
+	}
+	loc.LogicalLocations = []sarifLogicalLocation{logical}
+	return loc
+}
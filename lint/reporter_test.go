@@ -0,0 +1,334 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func reporterResultSet(t *testing.T) *ResultSet {
+	d, err := NewDashboard([]byte(`{
+		"title": "test",
+		"editable": true,
+		"templating": { "list": [ { "name": "datasource", "type": "datasource", "query": "prometheus" } ] },
+		"panels": [ { "id": 1, "type": "timeseries", "title": "cpu", "targets": [ { "refId": "A", "expr": "rate(foo[5m])" } ] } ]
+	}`))
+	require.NoError(t, err)
+	d.Source = "dashboards/test.json"
+
+	rules := RuleSet{rules: []Rule{NewUneditableRule(), NewTargetRateIntervalRule(), NewPanelTitleDescriptionRule()}}
+	rs, err := rules.Lint([]Dashboard{d})
+	require.NoError(t, err)
+	rs.Configure(&ConfigurationFile{
+		Warnings: map[string]*ConfigurationRuleEntries{"panel-title-description-rule": {}},
+	})
+	return rs
+}
+
+func TestNewReporter(t *testing.T) {
+	require.Equal(t, []string{"html", "json", "prometheus", "sarif", "tty"}, ReporterFormats())
+	r, err := NewReporter("json")
+	require.NoError(t, err)
+	require.Equal(t, JSONReporter{}, r)
+	_, err = NewReporter("xml")
+	require.EqualError(t, err, "unknown output format 'xml', must be one of html, json, prometheus, sarif, tty")
+}
+
+func TestTTYReporter(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, TTYReporter{}.Report(&buf, reporterResultSet(t)))
+	// A buffer is not a terminal, so no ANSI codes are written.
+	require.Equal(t, `Checks that each panel has a title and description.
+[⚠️] Dashboard 'test', panel 'cpu' has missing title or description, currently has title 'cpu' and description: ''
+Checks that each target uses $__rate_interval.
+[❌] Dashboard 'test', panel 'cpu', target idx '0' invalid PromQL query 'rate(foo[5m])': should use $__rate_interval
+Checks that the dashboard is not editable.
+[❌] Dashboard 'test' is editable, it should be set to 'editable: false'
+`, buf.String())
+}
+
+func TestJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, JSONReporter{}.Report(&buf, reporterResultSet(t)))
+	require.JSONEq(t, `{"results": [
+		{"rule": "uneditable-dashboard", "severity": "error", "message": "Dashboard 'test' is editable, it should be set to 'editable: false'",
+		 "source": "dashboards/test.json", "dashboard": "test", "fixable": true},
+		{"rule": "target-rate-interval-rule", "severity": "error", "message": "Dashboard 'test', panel 'cpu', target idx '0' invalid PromQL query 'rate(foo[5m])': should use $__rate_interval",
+		 "source": "dashboards/test.json", "dashboard": "test", "panel": "cpu", "panelId": 1, "targetIdx": 0, "range": {"start": 8, "end": 12}, "fixable": true},
+		{"rule": "panel-title-description-rule", "severity": "warning", "message": "Dashboard 'test', panel 'cpu' has missing title or description, currently has title 'cpu' and description: ''",
+		 "source": "dashboards/test.json", "dashboard": "test", "panel": "cpu", "panelId": 1, "fixable": false}
+	], "summary": {
+		"total": {"error": 2, "warning": 1, "fixed": 0, "excluded": 0, "ok": 0},
+		"rules": {
+			"panel-title-description-rule": {"error": 0, "warning": 1, "fixed": 0, "excluded": 0, "ok": 0},
+			"target-rate-interval-rule": {"error": 1, "warning": 0, "fixed": 0, "excluded": 0, "ok": 0},
+			"uneditable-dashboard": {"error": 1, "warning": 0, "fixed": 0, "excluded": 0, "ok": 0}
+		},
+		"dashboards": {"dashboards/test.json": {"error": 2, "warning": 1, "fixed": 0, "excluded": 0, "ok": 0}},
+		"titles": {"dashboards/test.json": "test"},
+		"fixesApplied": 0,
+		"fixesAvailable": 2,
+		"scores": {"dashboards/test.json": 16.7}
+	}}`, buf.String())
+}
+
+func TestSARIFReporter(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, SARIFReporter{}.Report(&buf, reporterResultSet(t)))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+
+	var ruleIDs []string
+	for _, r := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, r.ID)
+	}
+	require.Equal(t, []string{"panel-title-description-rule", "target-rate-interval-rule", "uneditable-dashboard"}, ruleIDs)
+	require.Equal(t, docsURL("uneditable-dashboard"), run.Tool.Driver.Rules[2].HelpURI)
+
+	require.Len(t, run.Results, 3)
+	require.Equal(t, sarifResult{
+		RuleID:  "target-rate-interval-rule",
+		Level:   "error",
+		Message: sarifMessage{Text: "Dashboard 'test', panel 'cpu', target idx '0' invalid PromQL query 'rate(foo[5m])': should use $__rate_interval"},
+		Locations: []sarifLocation{{
+			PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "dashboards/test.json"}},
+			LogicalLocations: []sarifLogicalLocation{{Name: "target[0]", FullyQualifiedName: "test/cpu/target[0]", Kind: "object"}},
+		}},
+	}, run.Results[1])
+	require.Equal(t, "warning", run.Results[2].Level)
+}
+
+func TestTTYReporterGroupBy(t *testing.T) {
+	for _, tc := range []struct {
+		groupBy  GroupBy
+		expected string
+	}{
+		{
+			groupBy: GroupByDashboard,
+			expected: `Dashboard 'test' (dashboards/test.json)
+  [❌] Dashboard 'test' is editable, it should be set to 'editable: false' (uneditable-dashboard)
+  panel 'cpu'
+    [❌] Dashboard 'test', panel 'cpu', target idx '0' invalid PromQL query 'rate(foo[5m])': should use $__rate_interval (target-rate-interval-rule)
+    [⚠️] Dashboard 'test', panel 'cpu' has missing title or description, currently has title 'cpu' and description: '' (panel-title-description-rule)
+`,
+		},
+		{
+			groupBy: GroupByPanel,
+			expected: `Dashboard 'test' (dashboards/test.json)
+  [❌] Dashboard 'test' is editable, it should be set to 'editable: false' (uneditable-dashboard)
+Dashboard 'test' (dashboards/test.json), panel 'cpu'
+  [❌] Dashboard 'test', panel 'cpu', target idx '0' invalid PromQL query 'rate(foo[5m])': should use $__rate_interval (target-rate-interval-rule)
+  [⚠️] Dashboard 'test', panel 'cpu' has missing title or description, currently has title 'cpu' and description: '' (panel-title-description-rule)
+`,
+		},
+	} {
+		t.Run(string(tc.groupBy), func(t *testing.T) {
+			rs := reporterResultSet(t)
+			rs.config.GroupBy = tc.groupBy
+			var buf bytes.Buffer
+			require.NoError(t, TTYReporter{}.Report(&buf, rs))
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestTTYReporterGroupBySameTitle(t *testing.T) {
+	// Dashboards with the same title are grouped apart, by where they were read from.
+	rs := reporterResultSet(t)
+	d := *rs.results[0].Dashboard
+	d.Source = "dashboards/copy.json"
+	rs.AddResult(ResultContext{
+		Result:    RuleResults{[]FixableResult{{Result: Result{Severity: Error, Message: "Dashboard 'test' is editable"}}}},
+		Rule:      NewUneditableRule(),
+		Dashboard: &d,
+	})
+	require.Len(t, rs.ByDashboard(), 2)
+	require.Len(t, rs.ByPanel(), 3)
+
+	for _, tc := range []struct {
+		groupBy  GroupBy
+		expected string
+	}{
+		{
+			groupBy: GroupByDashboard,
+			expected: `Dashboard 'test' (dashboards/copy.json)
+  [❌] Dashboard 'test' is editable (uneditable-dashboard)
+Dashboard 'test' (dashboards/test.json)
+  [❌] Dashboard 'test' is editable, it should be set to 'editable: false' (uneditable-dashboard)
+`,
+		},
+		{
+			groupBy: GroupByPanel,
+			expected: `Dashboard 'test' (dashboards/copy.json)
+  [❌] Dashboard 'test' is editable (uneditable-dashboard)
+Dashboard 'test' (dashboards/test.json)
+  [❌] Dashboard 'test' is editable, it should be set to 'editable: false' (uneditable-dashboard)
+`,
+		},
+	} {
+		t.Run(string(tc.groupBy), func(t *testing.T) {
+			rs.config.GroupBy = tc.groupBy
+			var buf bytes.Buffer
+			require.NoError(t, TTYReporter{}.Report(&buf, rs))
+			require.True(t, strings.HasPrefix(buf.String(), tc.expected), buf.String())
+		})
+	}
+}
+
+func TestTTYReporterSummary(t *testing.T) {
+	rs := reporterResultSet(t)
+	rs.config.Summary = true
+	rs.config.GroupBy = GroupByDashboard
+	var buf bytes.Buffer
+	require.NoError(t, TTYReporter{}.Report(&buf, rs))
+	require.Contains(t, buf.String(), `
+Summary
+Rule                          Errors  Warnings  Fixed  Excluded  OK
+panel-title-description-rule  0       1         0      0         0
+target-rate-interval-rule     1       0         0      0         0
+uneditable-dashboard          1       0         0      0         0
+Dashboard                     Errors  Warnings  Fixed  Excluded  OK  Score
+test (dashboards/test.json)   2       1         0      0         0   16.7
+Total                         2       1         0      0         0
+Fixes applied: 0, available: 2
+`)
+}
+
+func TestResultSetViews(t *testing.T) {
+	rs := reporterResultSet(t)
+
+	byDashboard := rs.ByDashboard()
+	require.Len(t, byDashboard, 1)
+	var rules []string
+	for _, rc := range byDashboard["dashboards/test.json"] {
+		rules = append(rules, rc.Rule.Name())
+	}
+	require.Equal(t, []string{"uneditable-dashboard", "target-rate-interval-rule", "panel-title-description-rule"}, rules)
+
+	byPanel := rs.ByPanel()
+	require.Len(t, byPanel, 2)
+	require.Len(t, byPanel[PanelKey{Dashboard: "dashboards/test.json", DashboardTitle: "test"}], 1)
+	require.Len(t, byPanel[PanelKey{Dashboard: "dashboards/test.json", DashboardTitle: "test", Panel: "cpu", PanelID: 1, HasPanel: true}], 2)
+
+	keys := []PanelKey{
+		{Dashboard: "b", Panel: "a", HasPanel: true},
+		{Dashboard: "a", Panel: "b", HasPanel: true},
+		{Dashboard: "b"},
+		{Dashboard: "a", Panel: "a", PanelID: 2, HasPanel: true},
+		{Dashboard: "a", Panel: "a", PanelID: 1, HasPanel: true},
+	}
+	SortPanelKeys(keys)
+	require.Equal(t, []PanelKey{
+		{Dashboard: "a", Panel: "a", PanelID: 1, HasPanel: true},
+		{Dashboard: "a", Panel: "a", PanelID: 2, HasPanel: true},
+		{Dashboard: "a", Panel: "b", HasPanel: true},
+		{Dashboard: "b"},
+		{Dashboard: "b", Panel: "a", HasPanel: true},
+	}, keys)
+}
+
+func TestSummaryFixes(t *testing.T) {
+	rs := reporterResultSet(t)
+	d := *rs.results[0].Dashboard
+	require.Equal(t, 2, rs.AutoFix(&d))
+	sum := rs.Summary()
+	require.Equal(t, 2, sum.FixesApplied)
+	require.Equal(t, 0, sum.FixesAvailable)
+	require.Equal(t, Counts{Warning: 1, Fixed: 2}, sum.Total)
+}
+
+func TestTargetResultRanges(t *testing.T) {
+	for _, tc := range []struct {
+		rule    Rule
+		expr    string
+		problem string
+	}{
+		{rule: NewTargetRateIntervalRule(), expr: `sum(rate(foo{job=~"$job"}[5m])) / sum(rate(bar[$__rate_interval]))`, problem: "[5m]"},
+		{rule: NewTargetJobRule(), expr: `sum(rate(foo{job=~"$job"}[$__rate_interval])) / sum(rate(bar{instance=~"$instance"}[$__rate_interval]))`, problem: `bar{instance=~"$instance"}`},
+		{rule: NewTargetCounterAggRule(), expr: `sum(rate(foo_total[$__rate_interval])) / sum(bar_total)`, problem: "bar_total"},
+		{rule: NewTargetLogQLAutoRule(), expr: `sum(count_over_time({job="$job"} |~ "[a-z]" [5m]))`, problem: "[5m]"},
+	} {
+		t.Run(tc.rule.Name(), func(t *testing.T) {
+			datasource := Prometheus
+			if tc.rule.Name() == "target-logql-auto-rule" {
+				datasource = Loki
+			}
+			d := Dashboard{
+				Title: "dashboard",
+				Templating: struct {
+					List []Template `json:"list"`
+				}{List: []Template{{Type: "datasource", Query: datasource}}},
+				Panels: []Panel{{Title: "panel", Type: "timeseries", Targets: []Target{{Expr: tc.expr}}}},
+			}
+			rs := ResultSet{}
+			tc.rule.Lint(d, &rs)
+			require.Len(t, rs.results, 1)
+			r := rs.results[0].Result.Results[0]
+			require.Equal(t, Error, r.Severity)
+			require.NotNil(t, r.Range)
+			require.Equal(t, tc.problem, tc.expr[r.Range.Start:r.Range.End])
+		})
+	}
+}
+
+func TestHTMLReporter(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, HTMLReporter{}.Report(&buf, reporterResultSet(t)))
+	html := buf.String()
+
+	require.Contains(t, html, `<option>target-rate-interval-rule</option>`)
+	require.Contains(t, html, `<option>warning</option>`)
+	require.Contains(t, html, `<tr data-rule="uneditable-dashboard" data-severity="error" data-dashboard="test">`)
+	require.Contains(t, html, `<code>rate(foo<mark>[5m]</mark>)</code>`)
+	require.Contains(t, html, `<details><summary>Panel JSON</summary><pre>{
+  &#34;id&#34;: 1,`)
+	require.NotContains(t, html, "<script src=")
+	require.NotContains(t, html, "<link ")
+}
+
+func TestPrometheusReporter(t *testing.T) {
+	rs := reporterResultSet(t)
+	d := *rs.results[0].Dashboard
+	// Dashboards with the same title are told apart by their source.
+	d.Title = `team "a"`
+	d.Source = "dashboards/team.json"
+	rs.AddResult(ResultContext{
+		Result:    RuleResults{[]FixableResult{{Result: ResultSuccess}}},
+		Rule:      NewUneditableRule(),
+		Dashboard: &d,
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, PrometheusReporter{}.Report(&buf, rs))
+	require.Equal(t, `# HELP dashboard_lint_findings Number of lint results by dashboard, rule and severity.
+# TYPE dashboard_lint_findings gauge
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="uneditable-dashboard",severity="error"} 1
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="uneditable-dashboard",severity="warning"} 0
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="uneditable-dashboard",severity="fixed"} 0
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="uneditable-dashboard",severity="exclude"} 0
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="target-rate-interval-rule",severity="error"} 1
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="target-rate-interval-rule",severity="warning"} 0
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="target-rate-interval-rule",severity="fixed"} 0
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="target-rate-interval-rule",severity="exclude"} 0
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="panel-title-description-rule",severity="error"} 0
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="panel-title-description-rule",severity="warning"} 1
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="panel-title-description-rule",severity="fixed"} 0
+dashboard_lint_findings{dashboard="test",source="dashboards/test.json",rule="panel-title-description-rule",severity="exclude"} 0
+dashboard_lint_findings{dashboard="team \"a\"",source="dashboards/team.json",rule="uneditable-dashboard",severity="error"} 0
+dashboard_lint_findings{dashboard="team \"a\"",source="dashboards/team.json",rule="uneditable-dashboard",severity="warning"} 0
+dashboard_lint_findings{dashboard="team \"a\"",source="dashboards/team.json",rule="uneditable-dashboard",severity="fixed"} 0
+dashboard_lint_findings{dashboard="team \"a\"",source="dashboards/team.json",rule="uneditable-dashboard",severity="exclude"} 0
+# HELP dashboard_lint_score Quality score of the dashboard from 0 to 100, based on weighted rule results.
+# TYPE dashboard_lint_score gauge
+dashboard_lint_score{dashboard="team \"a\"",source="dashboards/team.json"} 100
+dashboard_lint_score{dashboard="test",source="dashboards/test.json"} 16.7
+# EOF
+`, buf.String())
+}
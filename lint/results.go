@@ -2,6 +2,7 @@ package lint
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sort"
 )
@@ -19,6 +20,17 @@ type Result struct {
 type FixableResult struct {
 	Result
 	Fix func(*Dashboard) // if nil, it cannot be fixed
+	// Range locates the problem in the query of the result's target, if known.
+	Range *QueryRange
+	// unfixed is the severity before AutoFix fixed the result.
+	unfixed Severity
+}
+
+// QueryRange is a range of byte offsets into the expression of a target, which reporters can
+// highlight.
+type QueryRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 type RuleResults struct {
@@ -27,19 +39,62 @@ type RuleResults struct {
 
 type TargetResult struct {
 	Result
-	Fix func(Dashboard, Panel, *Target)
+	Fix   func(Dashboard, Panel, *Target)
+	Range *QueryRange
 }
 
 type TargetRuleResults struct {
 	Results []TargetResult
 }
 
+func targetMessage(d Dashboard, p Panel, t Target, message string) string {
+	if d.library != nil {
+		return fmt.Sprintf("Library panel '%s', target idx '%d' %s", d.library.Name, t.Idx, message)
+	}
+	return fmt.Sprintf("Dashboard '%s', %spanel '%s'%s, target idx '%d' %s", d.Title, sectionNote(p), p.Title, libraryPanelNote(p), t.Idx, message)
+}
+
 func (r *TargetRuleResults) AddError(d Dashboard, p Panel, t Target, message string) {
 	r.Results = append(r.Results, TargetResult{
 		Result: Result{
 			Severity: Error,
-			Message:  fmt.Sprintf("Dashboard '%s', panel '%s', target idx '%d' %s", d.Title, p.Title, t.Idx, message),
+			Message:  targetMessage(d, p, t, message),
+		},
+	})
+}
+
+// AddErrorAt adds an error about the given range of the target's expression.
+func (r *TargetRuleResults) AddErrorAt(d Dashboard, p Panel, t Target, message string, rng QueryRange) {
+	r.Results = append(r.Results, TargetResult{
+		Result: Result{
+			Severity: Error,
+			Message:  targetMessage(d, p, t, message),
+		},
+		Range: &rng,
+	})
+}
+
+// AddWarningAt adds a warning about the given range of the target's expression.
+func (r *TargetRuleResults) AddWarningAt(d Dashboard, p Panel, t Target, message string, rng QueryRange) {
+	r.Results = append(r.Results, TargetResult{
+		Result: Result{
+			Severity: Warning,
+			Message:  targetMessage(d, p, t, message),
+		},
+		Range: &rng,
+	})
+}
+
+// AddFixableErrorAt adds an error about the given range of the target's expression, which fix
+// can fix.
+func (r *TargetRuleResults) AddFixableErrorAt(d Dashboard, p Panel, t Target, message string, rng QueryRange, fix func(Dashboard, Panel, *Target)) {
+	r.Results = append(r.Results, TargetResult{
+		Result: Result{
+			Severity: Error,
+			Message:  targetMessage(d, p, t, message),
 		},
+		Fix:   fix,
+		Range: &rng,
 	})
 }
 
@@ -52,16 +107,61 @@ type PanelRuleResults struct {
 	Results []PanelResult
 }
 
-func (r *PanelRuleResults) AddError(d Dashboard, p Panel, message string) {
-	msg := fmt.Sprintf("Dashboard '%s', panel '%s' %s", d.Title, p.Title, message)
+func panelMessage(d Dashboard, p Panel, message string) string {
+	if d.library != nil {
+		return fmt.Sprintf("Library panel '%s' %s", d.library.Name, message)
+	}
+	return fmt.Sprintf("Dashboard '%s', %s%s %s", d.Title, panelName(p), libraryPanelNote(p), message)
+}
+
+// panelName names the panel, along with the rows and tabs of a v2 layout it is in.
+func panelName(p Panel) string {
 	if p.Title == "" {
-		msg = fmt.Sprintf("Dashboard '%s', panel with id '%d' %s", d.Title, p.Id, message)
+		return fmt.Sprintf("%spanel with id '%d'", sectionNote(p), p.Id)
+	}
+	return fmt.Sprintf("%spanel '%s'", sectionNote(p), p.Title)
+}
+
+// sectionNote names the rows and tabs of a v2 layout the panel is in, if any.
+func sectionNote(p Panel) string {
+	if p.section == "" {
+		return ""
+	}
+	return p.section + ", "
+}
+
+// libraryPanelNote names the library panel the panel was resolved with, if any.
+func libraryPanelNote(p Panel) string {
+	if p.library == nil {
+		return ""
 	}
+	return fmt.Sprintf(" (library panel '%s')", p.library.Name)
+}
+
+func (r *PanelRuleResults) AddError(d Dashboard, p Panel, message string) {
+	r.Results = append(r.Results, PanelResult{
+		Result: Result{
+			Severity: Error,
+			Message:  panelMessage(d, p, message),
+		},
+	})
+}
 
+func (r *PanelRuleResults) AddFixableError(d Dashboard, p Panel, message string, fix func(Dashboard, *Panel)) {
 	r.Results = append(r.Results, PanelResult{
 		Result: Result{
 			Severity: Error,
-			Message:  msg,
+			Message:  panelMessage(d, p, message),
+		},
+		Fix: fix,
+	})
+}
+
+func (r *PanelRuleResults) AddWarning(d Dashboard, p Panel, message string) {
+	r.Results = append(r.Results, PanelResult{
+		Result: Result{
+			Severity: Warning,
+			Message:  panelMessage(d, p, message),
 		},
 	})
 }
@@ -107,6 +207,16 @@ func (r *DashboardRuleResults) AddWarning(d Dashboard, message string) {
 	})
 }
 
+func (r *DashboardRuleResults) AddFixableWarning(d Dashboard, message string, fix func(*Dashboard)) {
+	r.Results = append(r.Results, DashboardResult{
+		Result: Result{
+			Severity: Warning,
+			Message:  dashboardMessage(d, message),
+		},
+		Fix: fix,
+	})
+}
+
 // ResultContext is used by ResultSet to keep all the state data about a lint execution and it's results.
 type ResultContext struct {
 	Result    RuleResults
@@ -114,14 +224,29 @@ type ResultContext struct {
 	Dashboard *Dashboard
 	Panel     *Panel
 	Target    *Target
+	// LibraryPanel is the library panel the result is about, if the panel was resolved from one
+	// or the library panel is linted on its own.
+	LibraryPanel *LibraryPanel
 }
 
 func (r Result) TtyPrint() {
+	r.TtyFprint(os.Stdout)
+}
+
+// TtyFprint writes the result to w, with a colored symbol for its severity.
+func (r Result) TtyFprint(w io.Writer) {
+	_ = r.ttyFprint(w, true)
+}
+
+func (r Result) ttyFprint(w io.Writer, color bool) error {
 	var Reset = "\033[0m"
 	var Red = "\033[31m"
 	var Green = "\033[32m"
 	var Yellow = "\033[33m"
 	var Orange = "\033[38;5;208m"
+	if !color {
+		Reset, Red, Green, Yellow, Orange = "", "", "", "", ""
+	}
 	var sym string
 	switch s := r.Severity; s {
 	case Success:
@@ -135,10 +260,11 @@ func (r Result) TtyPrint() {
 	case Error:
 		sym = Red + "❌" + Reset
 	case Quiet:
-		return
+		return nil
 	}
 
-	_, _ = fmt.Fprintf(os.Stdout, "[%s] %s\n", sym, r.Message)
+	_, err := fmt.Fprintf(w, "[%s] %s\n", sym, r.Message)
+	return err
 }
 
 type ResultSet struct {
@@ -156,12 +282,26 @@ func (rs *ResultSet) Configure(c *ConfigurationFile) {
 
 // AddResult adds a result to the ResultSet, applying the current configuration if set
 func (rs *ResultSet) AddResult(r ResultContext) {
+	if r.Panel != nil && r.Panel.library != nil {
+		// A library panel is not part of the dashboard using it, so it is fixed on its own.
+		r.LibraryPanel = r.Panel.library
+		for i := range r.Result.Results {
+			r.Result.Results[i].Fix = nil
+		}
+	} else if r.Dashboard != nil && r.Dashboard.library != nil {
+		r.LibraryPanel = r.Dashboard.library
+	}
 	if rs.config != nil {
 		r = rs.config.Apply(r)
 	}
 	rs.results = append(rs.results, r)
 }
 
+// Results returns the results of every rule, for every dashboard, panel or target it was run for.
+func (rs *ResultSet) Results() []ResultContext {
+	return rs.results
+}
+
 func (rs *ResultSet) MaximumSeverity() Severity {
 	retVal := Success
 	for _, res := range rs.results {
@@ -187,38 +327,250 @@ func (rs *ResultSet) ByRule() map[string][]ResultContext {
 	return ret
 }
 
-func (rs *ResultSet) ReportByRule() {
-	byRule := rs.ByRule()
-	rules := make([]string, 0, len(byRule))
-	for r := range byRule {
-		rules = append(rules, r)
-	}
-	sort.Strings(rules)
-
-	for _, rule := range rules {
-		_, _ = fmt.Fprintln(os.Stdout, byRule[rule][0].Rule.Description())
-		for _, rr := range byRule[rule] {
-			for _, r := range rr.Result.Results {
-				if r.Severity == Exclude && !rs.config.Verbose {
-					continue
-				}
-				r.TtyPrint()
+// ByDashboard returns the results grouped by dashboard, by the key of dashboardKey, as titles
+// need not be unique. The results of each dashboard start with those about the dashboard
+// itself, followed by those of each panel sorted by title.
+func (rs *ResultSet) ByDashboard() map[string][]ResultContext {
+	ret := make(map[string][]ResultContext)
+	for _, res := range rs.results {
+		key := dashboardKey(res.Dashboard)
+		ret[key] = append(ret[key], res)
+	}
+	for _, dashboard := range ret {
+		sort.SliceStable(dashboard, func(i, j int) bool {
+			return panelKeyOf(dashboard[i]).less(panelKeyOf(dashboard[j]))
+		})
+	}
+	return ret
+}
+
+// PanelKey identifies a panel across dashboards. Results which are not about a panel have a
+// key with only the dashboard set.
+type PanelKey struct {
+	// Dashboard is the key of the dashboard, see dashboardKey, and DashboardTitle its title.
+	Dashboard      string
+	DashboardTitle string
+	Panel          string
+	PanelID        int
+	// HasPanel is false for results about the dashboard itself.
+	HasPanel bool
+}
+
+func panelKeyOf(r ResultContext) PanelKey {
+	k := PanelKey{Dashboard: dashboardKey(r.Dashboard)}
+	if r.Dashboard != nil {
+		k.DashboardTitle = r.Dashboard.Title
+	}
+	if r.Panel != nil {
+		k.Panel = r.Panel.Title
+		k.PanelID = r.Panel.Id
+		k.HasPanel = true
+	}
+	return k
+}
+
+func (k PanelKey) less(o PanelKey) bool {
+	if k.Dashboard != o.Dashboard {
+		return k.Dashboard < o.Dashboard
+	}
+	if k.HasPanel != o.HasPanel {
+		return !k.HasPanel
+	}
+	if k.Panel != o.Panel {
+		return k.Panel < o.Panel
+	}
+	return k.PanelID < o.PanelID
+}
+
+// SortPanelKeys sorts the keys by dashboard, then panel title, with the key of each dashboard
+// itself before those of its panels.
+func SortPanelKeys(keys []PanelKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].less(keys[j])
+	})
+}
+
+// ByPanel returns the results grouped by panel, including the results of the panel's targets.
+// Results about a dashboard itself are grouped under a key without a panel.
+func (rs *ResultSet) ByPanel() map[PanelKey][]ResultContext {
+	ret := make(map[PanelKey][]ResultContext)
+	for _, res := range rs.results {
+		k := panelKeyOf(res)
+		ret[k] = append(ret[k], res)
+	}
+	return ret
+}
+
+// Counts counts results by severity. Successful results are counted as OK whether or not they
+// are reported.
+type Counts struct {
+	Error    int `json:"error"`
+	Warning  int `json:"warning"`
+	Fixed    int `json:"fixed"`
+	Excluded int `json:"excluded"`
+	OK       int `json:"ok"`
+}
+
+func (c *Counts) add(s Severity) {
+	switch s {
+	case Error:
+		c.Error++
+	case Warning:
+		c.Warning++
+	case Fixed:
+		c.Fixed++
+	case Exclude:
+		c.Excluded++
+	case Success, Quiet:
+		c.OK++
+	}
+}
+
+// Summary counts the results of a lint run.
+type Summary struct {
+	Total Counts            `json:"total"`
+	Rules map[string]Counts `json:"rules"`
+	// Dashboards are the counts of each dashboard, by where it was read from, or its UID if that
+	// is not known, or else its title.
+	Dashboards map[string]Counts `json:"dashboards"`
+	// Titles are the titles of the dashboards, by the same keys.
+	Titles map[string]string `json:"titles"`
+	// FixesApplied is the number of results which were fixed.
+	FixesApplied int `json:"fixesApplied"`
+	// FixesAvailable is the number of warnings and errors which --fix would fix.
+	FixesAvailable int `json:"fixesAvailable"`
+	// Scores are the quality scores of the dashboards, see ResultSet.Scores.
+	Scores map[string]float64 `json:"scores"`
+}
+
+// Summary counts the results per severity, in total, per rule and per dashboard.
+func (rs *ResultSet) Summary() Summary {
+	sum := Summary{
+		Rules:      map[string]Counts{},
+		Dashboards: map[string]Counts{},
+		Titles:     map[string]string{},
+		Scores:     rs.Scores(),
+	}
+	for _, rc := range rs.results {
+		dashboard := dashboardKey(rc.Dashboard)
+		if rc.Dashboard != nil {
+			sum.Titles[dashboard] = rc.Dashboard.Title
+		}
+		rule := sum.Rules[rc.Rule.Name()]
+		dash := sum.Dashboards[dashboard]
+		for _, r := range rc.Result.Results {
+			sum.Total.add(r.Severity)
+			rule.add(r.Severity)
+			dash.add(r.Severity)
+			if r.Severity == Fixed {
+				sum.FixesApplied++
+			} else if r.Fix != nil && (r.Severity == Error || r.Severity == Warning) {
+				sum.FixesAvailable++
 			}
 		}
+		sum.Rules[rc.Rule.Name()] = rule
+		sum.Dashboards[dashboard] = dash
 	}
+	return sum
+}
+
+func (rs *ResultSet) ReportByRule() {
+	rs.ReportByRuleTo(os.Stdout)
+}
+
+// ReportByRuleTo writes the results to w, grouped by rule, like the TTYReporter.
+func (rs *ResultSet) ReportByRuleTo(w io.Writer) {
+	newTTYWriter(w, rs).byRule(rs)
 }
 
 func (rs *ResultSet) AutoFix(d *Dashboard) int {
 	changes := 0
 	for _, r := range rs.results {
 		for i, fixableResult := range r.Result.Results {
-			if fixableResult.Fix != nil {
+			if fixableResult.Fix != nil && fixableResult.Severity != Fixed {
 				// Fix is only present when something can be fixed
 				fixableResult.Fix(d)
 				changes++
+				r.Result.Results[i].unfixed = fixableResult.Severity
 				r.Result.Results[i].Severity = Fixed
 			}
 		}
 	}
 	return changes
 }
+
+// unfix restores the severity of the fixed results, so the fixes of a dry run are reported as
+// pending.
+func (rs *ResultSet) unfix() {
+	for _, r := range rs.results {
+		for i, fixableResult := range r.Result.Results {
+			if fixableResult.Severity == Fixed {
+				r.Result.Results[i].Severity = fixableResult.unfixed
+			}
+		}
+	}
+}
+
+// resultKey identifies what the results of a rule are about, across lint runs of a dashboard.
+type resultKey struct {
+	rule, panel string
+	target      int
+	hasPanel    bool
+	hasTarget   bool
+}
+
+func resultKeyOf(rc ResultContext) resultKey {
+	k := resultKey{rule: rc.Rule.Name()}
+	if rc.Panel != nil {
+		k.panel, k.hasPanel = rc.Panel.pointer, true
+	}
+	if rc.Target != nil {
+		k.target, k.hasTarget = rc.Target.Idx, true
+	}
+	return k
+}
+
+// carryFixed adds what prev fixed to the results of linting the fixed dashboard again. The fixed
+// results replace the success they led to, and are left out if the same result is reported
+// again, as the fix did not take.
+func (rs *ResultSet) carryFixed(prev *ResultSet) {
+	index := map[resultKey]int{}
+	for i, rc := range rs.results {
+		index[resultKeyOf(rc)] = i
+	}
+	for _, rc := range prev.results {
+		var fixed []FixableResult
+		for _, r := range rc.Result.Results {
+			if r.Severity == Fixed {
+				fixed = append(fixed, r)
+			}
+		}
+		if len(fixed) == 0 {
+			continue
+		}
+		i, ok := index[resultKeyOf(rc)]
+		if !ok {
+			rc.Result.Results = fixed
+			rs.results = append(rs.results, rc)
+			continue
+		}
+		current := rs.results[i].Result.Results
+		reported := map[string]bool{}
+		for _, r := range current {
+			reported[r.Message] = true
+		}
+		var carried []FixableResult
+		for _, r := range fixed {
+			if !reported[r.Message] {
+				carried = append(carried, r)
+			}
+		}
+		if len(carried) == 0 {
+			continue
+		}
+		if len(current) == 1 && current[0].Severity == Success {
+			current = nil
+		}
+		rs.results[i].Result.Results = append(carried, current...)
+	}
+}
@@ -0,0 +1,60 @@
+package lint
+
+import "fmt"
+
+// NewDashboardLayoutRule builds a lint rule which checks that every element of a v2 dashboard is
+// placed in its layout, that the layout only references elements which are defined, and that
+// panels, rows and tabs are repeated by a variable of the dashboard.
+func NewDashboardLayoutRule() *DashboardRuleFunc {
+	return &DashboardRuleFunc{
+		name:        "dashboard-layout-rule",
+		description: "Checks that the layout places every element, and repeats by defined variables.",
+		metadata: Metadata{
+			Category:  CategoryDashboard,
+			Severity:  Error,
+			DocsURL:   docsURL("dashboard-layout-rule"),
+			Rationale: "Elements which are not placed are never shown, references to missing elements leave a gap, and a repeat by a variable which is not defined, or has a single value, shows the panel just once.",
+		},
+		fn: func(d Dashboard) DashboardRuleResults {
+			r := DashboardRuleResults{}
+			if d.v2 != nil {
+				for _, key := range d.v2.unplaced {
+					r.AddError(d, fmt.Sprintf("has element '%s', which is not placed in the layout", key))
+				}
+				for _, key := range d.v2.missing {
+					r.AddError(d, fmt.Sprintf("layout references element '%s', which is not defined", key))
+				}
+			}
+
+			templates := map[string]Template{}
+			for _, t := range d.Templating.List {
+				templates[t.Name] = t
+			}
+			for _, p := range d.GetPanels() {
+				if p.Repeat == "" {
+					continue
+				}
+				t, ok := templates[p.Repeat]
+				switch {
+				case !ok:
+					r.AddError(d, fmt.Sprintf("%s repeats by variable '%s', which is not defined", repeatedName(p), p.Repeat))
+				case !t.Multi && !t.IncludeAll:
+					r.AddWarning(d, fmt.Sprintf("%s repeats by variable '%s', which has neither multiple values nor an All option, so it is shown once", repeatedName(p), p.Repeat))
+				}
+			}
+			return r
+		},
+	}
+}
+
+// repeatedName names a repeated panel, row or tab, along with the rows and tabs it is in.
+func repeatedName(p Panel) string {
+	if p.Type != "row" && p.Type != "tab" {
+		return panelName(p)
+	}
+	name := fmt.Sprintf("%s '%s'", p.Type, p.Title)
+	if p.Title == "" {
+		name = p.Type
+	}
+	return sectionNote(p) + name
+}
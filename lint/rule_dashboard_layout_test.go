@@ -0,0 +1,107 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const v2LayoutDashboard = `{
+	"apiVersion": "dashboard.grafana.app/v2",
+	"kind": "Dashboard",
+	"spec": {
+		"title": "layout",
+		"variables": [
+			{ "kind": "QueryVariable", "spec": { "name": "instance", "multi": true, "includeAll": true } },
+			{ "kind": "CustomVariable", "spec": { "name": "env", "query": "prod,dev" } }
+		],
+		"elements": {
+			"cpu": { "kind": "Panel", "spec": { "id": 1, "title": "CPU", "vizConfig": { "group": "timeseries" } } },
+			"memory": { "kind": "Panel", "spec": { "id": 2, "title": "Memory", "vizConfig": { "group": "timeseries" } } },
+			"disk": { "kind": "Panel", "spec": { "id": 3, "title": "Disk", "vizConfig": { "group": "timeseries" } } },
+			"network": { "kind": "Panel", "spec": { "id": 4, "title": "Network", "vizConfig": { "group": "timeseries" } } }
+		},
+		"layout": {
+			"kind": "RowsLayout",
+			"spec": {
+				"rows": [
+					{
+						"kind": "RowsLayoutRow",
+						"spec": {
+							"title": "Overview",
+							"layout": {
+								"kind": "GridLayout",
+								"spec": { "items": [ { "kind": "GridLayoutItem", "spec": { "x": 0, "y": 0, "width": 12, "height": 8, "element": { "kind": "ElementReference", "name": "cpu" } } } ] }
+							}
+						}
+					},
+					{
+						"kind": "RowsLayoutRow",
+						"spec": {
+							"title": "Details",
+							"repeat": { "mode": "variable", "value": "env" },
+							"layout": {
+								"kind": "TabsLayout",
+								"spec": {
+									"tabs": [
+										{
+											"kind": "TabsLayoutTab",
+											"spec": {
+												"title": "Memory",
+												"layout": {
+													"kind": "AutoGridLayout",
+													"spec": {
+														"items": [
+															{ "kind": "AutoGridLayoutItem", "spec": { "element": { "kind": "ElementReference", "name": "memory" }, "repeat": { "mode": "variable", "value": "instance" } } },
+															{ "kind": "AutoGridLayoutItem", "spec": { "element": { "kind": "ElementReference", "name": "swap" }, "repeat": { "mode": "variable", "value": "node" } } }
+														]
+													}
+												}
+											}
+										},
+										{
+											"kind": "TabsLayoutTab",
+											"spec": {
+												"layout": {
+													"kind": "AutoGridLayout",
+													"spec": { "items": [ { "kind": "AutoGridLayoutItem", "spec": { "element": { "kind": "ElementReference", "name": "disk" }, "repeat": { "mode": "variable", "value": "node" } } } ] }
+												}
+											}
+										}
+									]
+								}
+							}
+						}
+					}
+				]
+			}
+		}
+	}
+}`
+
+func TestDashboardLayoutRule(t *testing.T) {
+	d, err := NewDashboard([]byte(v2LayoutDashboard))
+	require.NoError(t, err)
+
+	testMultiResultRule(t, NewDashboardLayoutRule(), d, []Result{
+		{Severity: Error, Message: "Dashboard 'layout' has element 'network', which is not placed in the layout"},
+		{Severity: Error, Message: "Dashboard 'layout' layout references element 'swap', which is not defined"},
+		{Severity: Warning, Message: "Dashboard 'layout' row 'Details' repeats by variable 'env', which has neither multiple values nor an All option, so it is shown once"},
+		{Severity: Error, Message: "Dashboard 'layout' row 'Details', tab 2, panel 'Disk' repeats by variable 'node', which is not defined"},
+	})
+
+	t.Run("classic", func(t *testing.T) {
+		d := Dashboard{
+			Title:  "classic",
+			Panels: []Panel{{Id: 1, Title: "CPU", Repeat: "instance"}},
+		}
+		d.Templating.List = []Template{{Name: "instance", Type: "query", IncludeAll: true}}
+		testRule(t, NewDashboardLayoutRule(), d, ResultSuccess)
+
+		d.Templating.List = nil
+		testRule(t, NewDashboardLayoutRule(), d, Result{
+			Severity: Error,
+			Message:  "Dashboard 'classic' panel 'CPU' repeats by variable 'instance', which is not defined",
+		})
+	})
+}
@@ -0,0 +1,42 @@
+package lint
+
+import "fmt"
+
+// NewLibraryPanelRule builds a lint rule which checks that the library panels the dashboard
+// uses are found in the library panels it is linted with, see Dashboard.ResolveLibraryPanels.
+func NewLibraryPanelRule() *DashboardRuleFunc {
+	return &DashboardRuleFunc{
+		name:        "library-panel-rule",
+		description: "Checks that the library panels used by the dashboard are found.",
+		metadata: Metadata{
+			Category:  CategoryDashboard,
+			Severity:  Error,
+			DocsURL:   docsURL("library-panel-rule"),
+			Rationale: "A library panel which is not found cannot be linted, and the dashboard shows an error in its place if it is missing from Grafana too.",
+		},
+		fn: func(d Dashboard) DashboardRuleResults {
+			r := DashboardRuleResults{}
+			if d.libraryPanels == nil {
+				// Without library panels, references are not resolved at all.
+				return r
+			}
+			var check func(panels []Panel)
+			check = func(panels []Panel) {
+				for _, p := range panels {
+					if p.LibraryPanel != nil && p.library == nil {
+						if p.Title == "" {
+							p.Title = p.LibraryPanel.Name
+						}
+						r.AddError(d, fmt.Sprintf("%s uses library panel '%s', which is not found", panelName(p), p.LibraryPanel.UID))
+					}
+					check(p.Panels)
+				}
+			}
+			for _, row := range d.Rows {
+				check(row.Panels)
+			}
+			check(d.Panels)
+			return r
+		},
+	}
+}
@@ -0,0 +1,25 @@
+package lint
+
+import "testing"
+
+func TestLibraryPanelRule(t *testing.T) {
+	d := Dashboard{
+		Title: "test",
+		Rows: []Row{{Panels: []Panel{
+			{Id: 1, LibraryPanel: &LibraryPanelRef{UID: "cpu", Name: "CPU"}},
+		}}},
+		Panels: []Panel{
+			{Id: 2, Type: "row", Title: "row", Panels: []Panel{
+				{Id: 3, Title: "Memory", LibraryPanel: &LibraryPanelRef{UID: "memory", Name: "Memory"}},
+			}},
+		},
+	}
+	// Without library panels, references are not checked.
+	testRule(t, NewLibraryPanelRule(), d, ResultSuccess)
+
+	d.ResolveLibraryPanels(LibraryPanels{"cpu": {UID: "cpu", Name: "CPU", Model: Panel{Type: "timeseries", Title: "CPU usage"}}})
+	testRule(t, NewLibraryPanelRule(), d, Result{
+		Severity: Error,
+		Message:  "Dashboard 'test' panel 'Memory' uses library panel 'memory', which is not found",
+	})
+}
@@ -2,12 +2,21 @@ package lint
 
 import (
 	"fmt"
+	"strings"
 )
 
 func NewPanelDatasourceRule() *PanelRuleFunc {
 	return &PanelRuleFunc{
 		name:        "panel-datasource-rule",
 		description: "Checks that each panel uses the templated datasource.",
+		metadata: Metadata{
+			Category:  CategoryPanel,
+			Severity:  Error,
+			Weight:    2,
+			Fixable:   true,
+			DocsURL:   docsURL("panel-datasource-rule"),
+			Rationale: "Panels pinned to a fixed data source ignore the dashboard's data source variable, so switching data source only changes some of the panels.",
+		},
 		fn: func(d Dashboard, p Panel) PanelRuleResults {
 			r := PanelRuleResults{}
 
@@ -27,7 +36,34 @@ func NewPanelDatasourceRule() *PanelRuleFunc {
 				}
 				_, ok := availableDsUids[string(src.UID)]
 				if !ok {
-					r.AddError(d, p, fmt.Sprintf("does not use a templated datasource, uses '%s'", src.UID))
+					message := fmt.Sprintf("does not use a templated datasource, uses '%s'", src.UID)
+					if t := matchingTemplatedDatasource(templatedDs, src); t != nil {
+						r.AddFixableError(d, p, message, fixPanelDatasourceRule(*t))
+					} else {
+						// Without a single matching templated datasource, it's not clear what to use.
+						r.AddWarning(d, p, message)
+					}
+					return r
+				}
+
+				// The fix of the panel fixes its targets as well, so they are only checked on
+				// their own when the panel uses a templated datasource.
+				for _, target := range p.Targets {
+					ds, err := target.GetDataSource()
+					if target.Datasource == nil || err != nil {
+						continue
+					}
+					if _, ok := availableDsUids[ds.UID]; ok {
+						continue
+					}
+					result := PanelResult{Result: Result{
+						Severity: Warning,
+						Message:  targetMessage(d, p, target, fmt.Sprintf("does not use a templated datasource, uses '%s'", ds.UID)),
+					}}
+					if t := matchingTemplatedDatasource(templatedDs, ds); t != nil {
+						result.Severity, result.Fix = Error, fixTargetDatasource(target.Idx, *t)
+					}
+					r.Results = append(r.Results, result)
 				}
 			}
 
@@ -35,3 +71,66 @@ func NewPanelDatasourceRule() *PanelRuleFunc {
 		},
 	}
 }
+
+// matchingTemplatedDatasource returns the datasource variable to use instead of the datasource,
+// if exactly one has its plugin type. A datasource without a type matches every variable.
+func matchingTemplatedDatasource(templates []Template, ds Datasource) *Template {
+	var match *Template
+	for i, t := range templates {
+		if ds.Type != "" && t.Query != ds.Type {
+			continue
+		}
+		if match != nil {
+			return nil
+		}
+		match = &templates[i]
+	}
+	return match
+}
+
+// fixPanelDatasourceRule points the panel, and the targets with a datasource of their own, at
+// the datasource variable.
+func fixPanelDatasourceRule(t Template) func(Dashboard, *Panel) {
+	return func(_ Dashboard, p *Panel) {
+		p.Datasource = templatedDatasource(p.Datasource, t)
+		for i, target := range p.Targets {
+			ds, err := target.GetDataSource()
+			if target.Datasource == nil || err != nil || strings.HasPrefix(ds.UID, "$") {
+				continue
+			}
+			if ds.Type != "" && ds.Type != t.Query {
+				continue
+			}
+			p.Targets[i].Datasource = templatedDatasource(target.Datasource, t)
+		}
+	}
+}
+
+// fixTargetDatasource points the target at the datasource variable.
+func fixTargetDatasource(idx int, t Template) func(Dashboard, *Panel) {
+	return func(_ Dashboard, p *Panel) {
+		if idx < len(p.Targets) {
+			p.Targets[idx].Datasource = templatedDatasource(p.Targets[idx].Datasource, t)
+		}
+	}
+}
+
+// templatedDatasource returns a reference to the datasource variable in the form of the
+// datasource it replaces: a string, or an object with a uid and type.
+func templatedDatasource(old interface{}, t Template) interface{} {
+	uid := fmt.Sprintf("$%s", t.Name)
+	if _, ok := old.(string); ok {
+		return uid
+	}
+	ds := map[string]interface{}{}
+	if m, ok := old.(map[string]interface{}); ok {
+		for k, v := range m {
+			ds[k] = v
+		}
+	}
+	ds["uid"] = uid
+	if t.Query != "" {
+		ds["type"] = t.Query
+	}
+	return ds
+}
@@ -14,9 +14,10 @@ func TestPanelDatasource(t *testing.T) {
 		panel     Panel
 		templates []Template
 	}{
+		// Without a templated datasource to use instead, it can't be fixed.
 		{
 			result: Result{
-				Severity: Error,
+				Severity: Warning,
 				Message:  "Dashboard 'test', panel 'bar' does not use a templated datasource, uses 'foo'",
 			},
 			panel: Panel{
@@ -77,6 +78,24 @@ func TestPanelDatasource(t *testing.T) {
 				},
 			},
 		},
+		{
+			result: Result{
+				Severity: Error,
+				Message:  "Dashboard 'test', panel 'bar', target idx '0' does not use a templated datasource, uses 'foo'",
+			},
+			panel: Panel{
+				Type:       "singlestat",
+				Datasource: "$datasource",
+				Title:      "bar",
+				Targets:    []Target{{Datasource: "foo"}},
+			},
+			templates: []Template{
+				{
+					Type: "datasource",
+					Name: "datasource",
+				},
+			},
+		},
 	} {
 		testRule(t, linter, Dashboard{
 			Title:  "test",
@@ -88,6 +107,106 @@ func TestPanelDatasource(t *testing.T) {
 	}
 }
 
+func TestPanelDatasourceFix(t *testing.T) {
+	prometheus := Template{Type: "datasource", Name: "datasource", Query: "prometheus"}
+	loki := Template{Type: "datasource", Name: "logs", Query: "loki"}
+
+	for _, tc := range []struct {
+		name      string
+		panel     Panel
+		templates []Template
+		severity  Severity
+		expected  Panel
+	}{
+		{
+			name:      "string",
+			panel:     Panel{Type: "timeseries", Datasource: "Prometheus"},
+			templates: []Template{prometheus},
+			severity:  Fixed,
+			expected:  Panel{Type: "timeseries", Datasource: "$datasource"},
+		},
+		{
+			name: "object and targets",
+			panel: Panel{Type: "timeseries",
+				Datasource: map[string]interface{}{"type": "loki", "uid": "abc"},
+				Targets: []Target{
+					{Datasource: map[string]interface{}{"type": "loki", "uid": "abc"}},
+					{Datasource: "$logs"},
+					{},
+				},
+			},
+			templates: []Template{prometheus, loki},
+			severity:  Fixed,
+			expected: Panel{Type: "timeseries",
+				Datasource: map[string]interface{}{"type": "loki", "uid": "$logs"},
+				Targets: []Target{
+					{Datasource: map[string]interface{}{"type": "loki", "uid": "$logs"}},
+					{Idx: 1, Datasource: "$logs"},
+					{Idx: 2},
+				},
+			},
+		},
+		{
+			// Targets are checked on their own when the panel uses the datasource variable.
+			name: "templated panel and hard-coded targets",
+			panel: Panel{Type: "timeseries",
+				Datasource: "$datasource",
+				Targets: []Target{
+					{Datasource: "$datasource"},
+					{Datasource: map[string]interface{}{"type": "prometheus", "uid": "abc"}},
+				},
+			},
+			templates: []Template{prometheus},
+			severity:  Fixed,
+			expected: Panel{Type: "timeseries",
+				Datasource: "$datasource",
+				Targets: []Target{
+					{Datasource: "$datasource"},
+					{Idx: 1, Datasource: map[string]interface{}{"type": "prometheus", "uid": "$datasource"}},
+				},
+			},
+		},
+		{
+			name: "target without matching type",
+			panel: Panel{Type: "timeseries",
+				Datasource: "$datasource",
+				Targets:    []Target{{Datasource: map[string]interface{}{"type": "loki", "uid": "abc"}}},
+			},
+			templates: []Template{prometheus},
+			severity:  Warning,
+			expected: Panel{Type: "timeseries",
+				Datasource: "$datasource",
+				Targets:    []Target{{Datasource: map[string]interface{}{"type": "loki", "uid": "abc"}}},
+			},
+		},
+		{
+			name:      "no matching type",
+			panel:     Panel{Type: "timeseries", Datasource: map[string]interface{}{"type": "prometheus", "uid": "abc"}},
+			templates: []Template{loki},
+			severity:  Warning,
+			expected:  Panel{Type: "timeseries", Datasource: map[string]interface{}{"type": "prometheus", "uid": "abc"}},
+		},
+		{
+			name:      "ambiguous",
+			panel:     Panel{Type: "timeseries", Datasource: "Prometheus"},
+			templates: []Template{prometheus, loki},
+			severity:  Warning,
+			expected:  Panel{Type: "timeseries", Datasource: "Prometheus"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Dashboard{Title: "test", Panels: []Panel{tc.panel}}
+			d.Templating.List = tc.templates
+
+			rs := ResultSet{}
+			NewPanelDatasourceRule().Lint(d, &rs)
+			rs.AutoFix(&d)
+			require.Equal(t, tc.severity, rs.MaximumSeverity())
+			require.Equal(t, tc.expected, d.Panels[0])
+		})
+	}
+}
+
 // testRule is a small helper that tests a lint rule and expects it to only return
 // a single result.
 func testRule(t *testing.T, rule Rule, d Dashboard, result Result) {
@@ -4,6 +4,12 @@ func NewPanelNoTargetsRule() *PanelRuleFunc {
 	return &PanelRuleFunc{
 		name:        "panel-no-targets-rule",
 		description: "Checks that each panel has at least one target.",
+		metadata: Metadata{
+			Category:  CategoryPanel,
+			Severity:  Error,
+			DocsURL:   docsURL("panel-no-targets-rule"),
+			Rationale: "A data panel without any queries renders nothing, which usually means a query was lost while editing the dashboard.",
+		},
 		fn: func(d Dashboard, p Panel) PanelRuleResults {
 			r := PanelRuleResults{}
 			switch p.Type {
@@ -6,6 +6,12 @@ func NewPanelTitleDescriptionRule() *PanelRuleFunc {
 	return &PanelRuleFunc{
 		name:        "panel-title-description-rule",
 		description: "Checks that each panel has a title and description.",
+		metadata: Metadata{
+			Category:  CategoryPanel,
+			Severity:  Error,
+			DocsURL:   docsURL("panel-title-description-rule"),
+			Rationale: "A title and a description tell viewers what a panel shows and how to interpret it without reading its queries.",
+		},
 		fn: func(d Dashboard, p Panel) PanelRuleResults {
 			r := PanelRuleResults{}
 			switch p.Type {
@@ -69,6 +69,12 @@ func NewPanelUnitsRule() *PanelRuleFunc {
 	return &PanelRuleFunc{
 		name:        "panel-units-rule",
 		description: "Checks that each panel uses has valid units defined.",
+		metadata: Metadata{
+			Category:  CategoryPanel,
+			Severity:  Error,
+			DocsURL:   docsURL("panel-units-rule"),
+			Rationale: "Without a unit Grafana cannot format values, so viewers have to guess whether a number is bytes, seconds or a ratio.",
+		},
 		fn: func(d Dashboard, p Panel) PanelRuleResults {
 			r := PanelRuleResults{}
 			switch p.Type {
@@ -5,36 +5,48 @@ import (
 	"strings"
 
 	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/promql/parser/posrange"
 )
 
 func NewTargetCounterAggRule() *TargetRuleFunc {
 	return &TargetRuleFunc{
 		name:        "target-counter-agg-rule",
 		description: "Checks that any counter metric (ending in _total) is aggregated with rate, irate, or increase.",
+		metadata: Metadata{
+			Category:    CategoryTarget,
+			Datasources: []string{Prometheus},
+			Severity:    Error,
+			DocsURL:     docsURL("target-counter-agg-rule"),
+			Rationale:   "The raw value of a counter only ever goes up and resets on restart. It is only meaningful when turned into a rate or an increase.",
+		},
 		fn: func(d Dashboard, p Panel, t Target) TargetRuleResults {
 			r := TargetRuleResults{}
-			expr, err := parsePromQL(t.Expr, d.Templating.List)
+			expr, offsets, err := parsePromQLMapped(t.Expr, d.Templating.List)
 			if err != nil {
 				// Invalid PromQL is another rule
 				return r
 			}
 
-			err = parser.Walk(newInspector(), expr, nil)
+			var problem posrange.PositionRange
+			err = parser.Walk(newInspector(&problem), expr, nil)
 			if err != nil {
-				r.AddError(d, p, t, err.Error())
+				r.AddErrorAt(d, p, t, err.Error(), offsets.queryRange(problem))
 			}
 			return r
 		},
 	}
 }
 
-func newInspector() inspector {
+// newInspector returns an inspector which fails on counters which are not aggregated, and sets
+// problem to the position of the counter.
+func newInspector(problem *posrange.PositionRange) inspector {
 	return func(node parser.Node, parents []parser.Node) error {
 		// We're looking for either a VectorSelector. This skips any other node type.
 		selector, ok := node.(*parser.VectorSelector)
 		if !ok {
 			return nil
 		}
+		*problem = selector.PositionRange()
 
 		errmsg := fmt.Errorf("counter metric '%s' is not aggregated with rate, irate, or increase", node.String())
 
@@ -0,0 +1,101 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/promql/parser/posrange"
+)
+
+// NewTargetGroupByRule builds a lint rule which checks that the PromQL queries which aggregate
+// group by a group by variable of the dashboard, e.g. `sum by ($groupby) (...)`.
+func NewTargetGroupByRule() *TargetRuleFunc {
+	return &TargetRuleFunc{
+		name:        "target-groupby-rule",
+		description: "Checks that aggregating PromQL queries group by the group by variable.",
+		metadata: Metadata{
+			Category:    CategoryTarget,
+			Datasources: []string{Prometheus},
+			Severity:    Warning,
+			DocsURL:     docsURL("target-groupby-rule"),
+			Rationale:   "Prometheus queries only group by the labels of a group by variable when they reference it. Aggregations which do not reference it ignore the labels selected by the user.",
+		},
+		fn: func(d Dashboard, p Panel, t Target) TargetRuleResults {
+			r := TargetRuleResults{}
+			groupBy := map[string]bool{}
+			var names []string
+			for _, v := range d.GetTemplateByType("groupby") {
+				groupBy[v.Name] = true
+				names = append(names, fmt.Sprintf("'$%s'", v.Name))
+			}
+			if len(groupBy) == 0 || isLokiTarget(d, t) {
+				return r
+			}
+			if ds := getTemplateDatasource(d); ds == nil || ds.Query != Prometheus {
+				// Other datasources don't have rules yet
+				return r
+			}
+
+			node, offsets, err := parsePromQLMapped(t.Expr, d.Templating.List)
+			if err != nil {
+				// Invalid PromQL is another rule
+				return r
+			}
+
+			// grouped are the ranges of the expanded query the group by variables were expanded to.
+			var grouped []posrange.PositionRange
+			for _, v := range offsets {
+				if groupBy[variableName(t.Expr[v.origStart:v.origEnd])] {
+					grouped = append(grouped, posrange.PositionRange{Start: posrange.Pos(v.start), End: posrange.Pos(v.end)})
+				}
+			}
+
+			var outermost *parser.AggregateExpr
+			ok := false
+			parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+				agg, isAgg := n.(*parser.AggregateExpr)
+				if !isAgg {
+					return nil
+				}
+				if outermost == nil {
+					outermost = agg
+				}
+				for _, g := range grouped {
+					if !agg.Without && inGrouping(agg, g) {
+						ok = true
+					}
+				}
+				return nil
+			})
+			if outermost != nil && !ok {
+				r.AddWarningAt(d, p, t, fmt.Sprintf("aggregates without grouping by the group by variable %s", strings.Join(names, ", ")),
+					offsets.queryRange(outermost.PositionRange()))
+			}
+			return r
+		},
+	}
+}
+
+// inGrouping reports whether the range is in the grouping of the aggregation, that is in the
+// aggregation, but not in its parameter or the expression it aggregates.
+func inGrouping(agg *parser.AggregateExpr, r posrange.PositionRange) bool {
+	within := func(outer posrange.PositionRange) bool {
+		return outer.Start <= r.Start && r.End <= outer.End
+	}
+	if !within(agg.PositionRange()) || within(agg.Expr.PositionRange()) {
+		return false
+	}
+	return agg.Param == nil || !within(agg.Param.PositionRange())
+}
+
+// variableName returns the name of the variable of a reference like $var, ${var:csv} or [[var]].
+func variableName(ref string) string {
+	m := variableRegexp.FindStringSubmatch(ref)
+	for _, name := range m[1:] {
+		if name != "" {
+			return strings.SplitN(name, ":", 2)[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetGroupByRule(t *testing.T) {
+	linter := NewTargetGroupByRule()
+	datasource := Template{Type: "datasource", Name: "datasource", Query: "prometheus"}
+	groupBy := Template{Type: "groupby", Name: "groupby", Current: RawTemplateValue{"text": []interface{}{"job", "instance"}, "value": []interface{}{"job", "instance"}}}
+
+	for _, tc := range []struct {
+		name      string
+		expr      string
+		templates []Template
+		result    Result
+	}{
+		{
+			name:      "by variable",
+			expr:      `sum by ($groupby) (rate(http_requests_total[$__rate_interval]))`,
+			templates: []Template{datasource, groupBy},
+			result:    ResultSuccess,
+		},
+		{
+			name:      "by labels and variable after the expression",
+			expr:      `sum(rate(http_requests_total[$__rate_interval])) by (cluster, ${groupby})`,
+			templates: []Template{datasource, groupBy},
+			result:    ResultSuccess,
+		},
+		{
+			name:      "nested aggregation",
+			expr:      `topk(5, sum by ([[groupby]]) (rate(http_requests_total[$__rate_interval])))`,
+			templates: []Template{datasource, groupBy},
+			result:    ResultSuccess,
+		},
+		{
+			name:      "no aggregation",
+			expr:      `rate(http_requests_total[$__rate_interval])`,
+			templates: []Template{datasource, groupBy},
+			result:    ResultSuccess,
+		},
+		{
+			name:      "no group by variable",
+			expr:      `sum by (job) (rate(http_requests_total[$__rate_interval]))`,
+			templates: []Template{datasource},
+			result:    ResultSuccess,
+		},
+		{
+			name:      "by other labels",
+			expr:      `sum by (job) (rate(http_requests_total[$__rate_interval]))`,
+			templates: []Template{datasource, groupBy},
+			result: Result{
+				Severity: Warning,
+				Message:  "Dashboard 'dashboard', panel 'panel', target idx '0' aggregates without grouping by the group by variable '$groupby'",
+			},
+		},
+		{
+			name:      "variable in the selector only",
+			expr:      `sum(rate(http_requests_total{$groupby=~".+"}[$__rate_interval]))`,
+			templates: []Template{datasource, {Type: "groupby", Name: "groupby"}},
+			result: Result{
+				Severity: Warning,
+				Message:  "Dashboard 'dashboard', panel 'panel', target idx '0' aggregates without grouping by the group by variable '$groupby'",
+			},
+		},
+		{
+			name:      "without",
+			expr:      `sum without ($groupby) (rate(http_requests_total[$__rate_interval]))`,
+			templates: []Template{datasource, groupBy},
+			result: Result{
+				Severity: Warning,
+				Message:  "Dashboard 'dashboard', panel 'panel', target idx '0' aggregates without grouping by the group by variable '$groupby'",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Dashboard{
+				Title:  "dashboard",
+				Panels: []Panel{{Title: "panel", Type: "timeseries", Targets: []Target{{Expr: tc.expr}}}},
+			}
+			d.Templating.List = tc.templates
+			testRule(t, linter, d, tc.result)
+		})
+	}
+
+	t.Run("range", func(t *testing.T) {
+		d := Dashboard{Title: "dashboard"}
+		d.Templating.List = []Template{datasource, groupBy}
+		expr := `topk(5, sum by (job) (rate(http_requests_total[$__rate_interval])))`
+		r := linter.fn(d, Panel{Title: "panel"}, Target{Expr: expr})
+		require.Len(t, r.Results, 1)
+		require.Equal(t, &QueryRange{Start: 0, End: len(expr)}, r.Results[0].Range)
+	})
+}
@@ -2,42 +2,168 @@ package lint
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql/parser"
 )
 
 func newTargetRequiredMatcherRule(matcher string) *TargetRuleFunc {
+	name := fmt.Sprintf("target-%s-rule", matcher)
+	value := fmt.Sprintf("$%s", matcher)
+	fix := fixTargetRequiredMatcherRule(matcher)
 	return &TargetRuleFunc{
-		name:        fmt.Sprintf("target-%s-rule", matcher),
-		description: fmt.Sprintf("Checks that every PromQL query has a %s matcher.", matcher),
+		name:        name,
+		description: fmt.Sprintf("Checks that every PromQL and LogQL query has a %s matcher.", matcher),
+		metadata: Metadata{
+			Category:    CategoryTarget,
+			Datasources: []string{Prometheus, Loki},
+			Severity:    Error,
+			DocsURL:     docsURL(name),
+			Rationale: fmt.Sprintf("Queries which do not filter on the $%s variable ignore the %s selected by the user, "+
+				"so the panel keeps showing data for every %s.", matcher, matcher, matcher),
+			Fixable: true,
+		},
 		fn: func(d Dashboard, p Panel, t Target) TargetRuleResults {
 			r := TargetRuleResults{}
+			// The fix references the variable, so it is only offered once the variable exists.
+			fix := fix
+			if getTemplate(d, matcher) == nil {
+				fix = nil
+			}
 			// TODO: The RuleSet should be responsible for routing rule checks based on their query type (prometheus, loki, mysql, etc)
 			// and for ensuring that the datasource is set.
+			if isLokiTarget(d, t) {
+				if fix == nil {
+					// Nothing adds the variable to Loki dashboards, so the matcher could not be used.
+					return r
+				}
+				if _, err := parseLogQL(t.Expr, d.Templating.List); err != nil {
+					// Invalid LogQL is another rule
+					return r
+				}
+				for _, s := range logQLStreamSelectors(t.Expr) {
+					matchers, ok := parseSelectorMatchers(t.Expr[s.Start+1 : s.End-1])
+					if !ok {
+						continue
+					}
+					var ms []*labels.Matcher
+					for _, m := range matchers {
+						ms = append(ms, m.matcher)
+					}
+					if err := checkForMatcher(ms, matcher, labels.MatchRegexp, value); err != nil {
+						r.AddFixableErrorAt(d, p, t, fmt.Sprintf("invalid LogQL query '%s': %v", t.Expr, err), s,
+							selectorFix(fix, t.Expr, s.Start, matcher, value))
+					}
+				}
+				return r
+			}
 			if t := getTemplateDatasource(d); t == nil || t.Query != Prometheus {
 				// Missing template datasource is a separate rule.
-				// Non prometheus datasources don't have rules yet
+				// Other datasources don't have rules yet
 				return r
 			}
 
-			node, err := parsePromQL(t.Expr, d.Templating.List)
+			node, offsets, err := parsePromQLMapped(t.Expr, d.Templating.List)
 			if err != nil {
 				// Invalid PromQL is another rule
 				return r
 			}
 
-			for _, selector := range parser.ExtractSelectors(node) {
-				if err := checkForMatcher(selector, matcher, labels.MatchRegexp, fmt.Sprintf("$%s", matcher)); err != nil {
-					r.AddError(d, p, t, fmt.Sprintf("invalid PromQL query '%s': %v", t.Expr, err))
+			parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+				selector, ok := n.(*parser.VectorSelector)
+				if !ok {
+					return nil
 				}
-			}
+				if err := checkForMatcher(selector.LabelMatchers, matcher, labels.MatchRegexp, value); err != nil {
+					start := offsets.original(int(selector.PositionRange().Start), false)
+					r.AddFixableErrorAt(d, p, t, fmt.Sprintf("invalid PromQL query '%s': %v", t.Expr, err),
+						offsets.queryRange(selector.PositionRange()), selectorFix(fix, t.Expr, start, matcher, value))
+				}
+				return nil
+			})
 
 			return r
 		},
 	}
 }
 
+// fixTargetRequiredMatcherRule adds the matcher, or corrects it, in every selector of the query.
+// Selectors which already have it are left alone, so the fix can be applied repeatedly.
+func fixTargetRequiredMatcherRule(matcher string) func(Dashboard, Panel, *Target) {
+	value := fmt.Sprintf("$%s", matcher)
+	return func(d Dashboard, _ Panel, t *Target) {
+		// starts are the offsets of the selectors in the expression.
+		var starts []int
+		if isLokiTarget(d, *t) {
+			for _, s := range logQLStreamSelectors(t.Expr) {
+				starts = append(starts, s.Start)
+			}
+		} else {
+			node, offsets, err := parsePromQLMapped(t.Expr, d.Templating.List)
+			if err != nil {
+				return
+			}
+			parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+				if selector, ok := n.(*parser.VectorSelector); ok {
+					starts = append(starts, offsets.original(int(selector.PositionRange().Start), false))
+				}
+				return nil
+			})
+		}
+
+		// Rewrite from the end, so the earlier offsets stay valid.
+		sort.Sort(sort.Reverse(sort.IntSlice(starts)))
+		for i, start := range starts {
+			if i == 0 || start != starts[i-1] {
+				t.Expr = withSelectorMatcher(t.Expr, start, matcher, value)
+			}
+		}
+	}
+}
+
+// selectorFix returns the fix for the selector at start, or nil if the fix cannot change it, e.g.
+// if its metric name is a variable.
+func selectorFix(fix func(Dashboard, Panel, *Target), expr string, start int, name, value string) func(Dashboard, Panel, *Target) {
+	if fix == nil || withSelectorMatcher(expr, start, name, value) == expr {
+		return nil
+	}
+	return fix
+}
+
+// withSelectorMatcher adds the matcher to the selector at start, which is either a metric name,
+// optionally followed by braces, or braces.
+func withSelectorMatcher(expr string, start int, name, value string) string {
+	open := start
+	if start >= len(expr) {
+		return expr
+	}
+	if expr[start] != '{' {
+		end := start
+		for end < len(expr) && isLabelChar(expr[end]) {
+			end++
+		}
+		if end == start {
+			// e.g. a metric name from a variable
+			return expr
+		}
+		open = end
+		for open < len(expr) && (expr[open] == ' ' || expr[open] == '\t') {
+			open++
+		}
+		if open == len(expr) || expr[open] != '{' {
+			body, _ := withMatcher("", name, value)
+			return expr[:end] + "{" + body + "}" + expr[end:]
+		}
+	}
+	end := closingBrace(expr, open)
+	if end < 0 {
+		return expr
+	}
+	body, _ := withMatcher(expr[open+1:end], name, value)
+	return expr[:open+1] + body + expr[end:]
+}
+
 func NewTargetJobRule() *TargetRuleFunc {
 	return newTargetRequiredMatcherRule("job")
 }
@@ -3,6 +3,8 @@ package lint
 import (
 	"fmt"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func testTargetRequiredMatcherRule(t *testing.T, matcher string) {
@@ -109,3 +111,162 @@ func TestTargetJobInstanceRule(t *testing.T) {
 	testTargetRequiredMatcherRule(t, "job")
 	testTargetRequiredMatcherRule(t, "instance")
 }
+
+func TestTargetJobInstanceRuleFix(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		datasource string
+		expr       string
+		expected   string
+	}{
+		{
+			name:       "promql missing braces",
+			datasource: Prometheus,
+			expr:       `sum(rate(foo[$__rate_interval])) / sum(bar offset 5m)`,
+			expected:   `sum(rate(foo{job=~"$job",instance=~"$instance"}[$__rate_interval])) / sum(bar{job=~"$job",instance=~"$instance"} offset 5m)`,
+		},
+		{
+			name:       "promql existing matchers",
+			datasource: Prometheus,
+			expr:       `sum by (cluster) (rate(foo{cluster=~"$cluster", job="node"}[$__rate_interval]))`,
+			expected:   `sum by (cluster) (rate(foo{cluster=~"$cluster", job=~"$job", instance=~"$instance"}[$__rate_interval]))`,
+		},
+		{
+			name:       "promql name matcher",
+			datasource: Prometheus,
+			expr:       `{__name__=~"foo_.*"} > 0`,
+			expected:   `{__name__=~"foo_.*",job=~"$job",instance=~"$instance"} > 0`,
+		},
+		{
+			name:       "promql already fixed",
+			datasource: Prometheus,
+			expr:       `foo{job=~"$job",instance=~"$instance"}`,
+			expected:   `foo{job=~"$job",instance=~"$instance"}`,
+		},
+		{
+			name:       "logql stream selectors",
+			datasource: Loki,
+			expr:       `sum(count_over_time({app="foo"} |= "{bar}" | label_format x="{{.y}}" [$__auto]))`,
+			expected:   `sum(count_over_time({app="foo",job=~"$job",instance=~"$instance"} |= "{bar}" | label_format x="{{.y}}" [$__auto]))`,
+		},
+		{
+			name:       "logql wrong matcher",
+			datasource: Loki,
+			expr:       `{job="foo", instance=~"$instance"} |= "error"`,
+			expected:   `{job=~"$job", instance=~"$instance"} |= "error"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Dashboard{Title: "dashboard", Panels: []Panel{{
+				Title:   "panel",
+				Type:    "timeseries",
+				Targets: []Target{{Expr: tc.expr}},
+			}}}
+			d.Templating.List = []Template{
+				{Type: "datasource", Query: tc.datasource},
+				{Type: "query", Name: "job"},
+				{Type: "query", Name: "instance"},
+			}
+
+			rules := []*TargetRuleFunc{NewTargetJobRule(), NewTargetInstanceRule()}
+			for _, rule := range rules {
+				rs := ResultSet{}
+				rule.Lint(d, &rs)
+				rs.AutoFix(&d)
+			}
+			require.Equal(t, tc.expected, d.Panels[0].Targets[0].Expr)
+
+			// Fixing again changes nothing.
+			for _, rule := range rules {
+				rs := ResultSet{}
+				rule.Lint(d, &rs)
+				require.Equal(t, Success, rs.MaximumSeverity())
+				fixTargetRequiredMatcherRule("job")(d, d.Panels[0], &d.Panels[0].Targets[0])
+				require.Equal(t, tc.expected, d.Panels[0].Targets[0].Expr)
+			}
+		})
+	}
+}
+
+func TestTargetJobInstanceRuleWithoutVariables(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		datasource string
+		result     Result
+	}{
+		{
+			// The template rules add the variable to Prometheus dashboards, the query is fixed then.
+			name:       "prometheus",
+			datasource: Prometheus,
+			result: Result{
+				Severity: Error,
+				Message:  "Dashboard 'dashboard', panel 'panel', target idx '0' invalid PromQL query 'sum(rate(foo[5m]))': job selector not found",
+			},
+		},
+		{
+			name:       "loki",
+			datasource: Loki,
+			result:     ResultSuccess,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Dashboard{Title: "dashboard", Panels: []Panel{{
+				Title:   "panel",
+				Type:    "timeseries",
+				Targets: []Target{{Expr: `sum(rate(foo[5m]))`}},
+			}}}
+			d.Templating.List = []Template{{Type: "datasource", Name: "datasource", Query: tc.datasource}}
+			testRule(t, NewTargetJobRule(), d, tc.result)
+
+			rs := ResultSet{}
+			NewTargetJobRule().Lint(d, &rs)
+			require.Zero(t, rs.AutoFix(&d))
+			require.Equal(t, `sum(rate(foo[5m]))`, d.Panels[0].Targets[0].Expr)
+		})
+	}
+}
+
+func TestTargetJobInstanceRuleVariableMetric(t *testing.T) {
+	d := Dashboard{Title: "dashboard", Panels: []Panel{{
+		Title:   "panel",
+		Type:    "timeseries",
+		Targets: []Target{{Expr: `sum(rate($metric[5m]))`}},
+	}}}
+	d.Templating.List = []Template{
+		{Type: "datasource", Name: "datasource", Query: Prometheus},
+		{Type: "query", Name: "job", Query: "label_values(up, job)"},
+		{Type: "custom", Name: "metric", Query: "up"},
+	}
+
+	// The selector's metric name is a variable, so the matcher cannot be added to it, and the
+	// result is not fixable.
+	rs := ResultSet{}
+	NewTargetJobRule().Lint(d, &rs)
+	require.Len(t, rs.results, 1)
+	require.Equal(t, Error, rs.results[0].Result.Results[0].Severity)
+	require.Nil(t, rs.results[0].Result.Results[0].Fix)
+	require.Zero(t, rs.AutoFix(&d))
+	require.Equal(t, `sum(rate($metric[5m]))`, d.Panels[0].Targets[0].Expr)
+}
+
+func TestIsLokiTarget(t *testing.T) {
+	d := Dashboard{}
+	d.Templating.List = []Template{
+		{Type: "datasource", Name: "logs", Query: Loki},
+		{Type: "datasource", Name: "metrics", Query: Prometheus},
+	}
+	for _, tc := range []struct {
+		name       string
+		datasource interface{}
+		expected   bool
+	}{
+		{name: "no datasource", expected: true},
+		{name: "own type", datasource: map[string]interface{}{"uid": "prom-1", "type": Prometheus}, expected: false},
+		{name: "referenced variable", datasource: map[string]interface{}{"uid": "${metrics}"}, expected: false},
+		{name: "referenced loki variable", datasource: "$logs", expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, isLokiTarget(d, Target{Datasource: tc.datasource}))
+		})
+	}
+}
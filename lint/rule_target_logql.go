@@ -8,6 +8,14 @@ func NewTargetLogQLRule() *TargetRuleFunc {
 	return &TargetRuleFunc{
 		name:        "target-logql-rule",
 		description: "Checks that each target uses a valid LogQL query.",
+		metadata: Metadata{
+			Category:    CategoryTarget,
+			Datasources: []string{Loki},
+			Severity:    Error,
+			Weight:      3,
+			DocsURL:     docsURL("target-logql-rule"),
+			Rationale:   "An invalid LogQL query fails at render time, leaving the panel empty.",
+		},
 		fn: func(d Dashboard, p Panel, t Target) TargetRuleResults {
 			r := TargetRuleResults{}
 
@@ -25,6 +25,14 @@ func NewTargetLogQLAutoRule() *TargetRuleFunc {
 	return &TargetRuleFunc{
 		name:        "target-logql-auto-rule",
 		description: "Checks that each Loki target uses $__auto for range vectors when appropriate.",
+		metadata: Metadata{
+			Category:    CategoryTarget,
+			Datasources: []string{Loki},
+			Severity:    Error,
+			Fixable:     true,
+			DocsURL:     docsURL("target-logql-auto-rule"),
+			Rationale:   "Fixed range intervals either miss data or become very expensive as the dashboard time range changes. $__auto adapts the interval to the time range and panel width.",
+		},
 		fn: func(d Dashboard, p Panel, t Target) TargetRuleResults {
 			r := TargetRuleResults{}
 
@@ -73,7 +81,14 @@ func NewTargetLogQLAutoRule() *TargetRuleFunc {
 			})
 
 			if hasFixedDuration {
-				r.AddError(d, p, t, "LogQL query uses fixed duration: should use $__auto")
+				message := "LogQL query uses fixed duration: should use $__auto"
+				if ranges, ok := fixedLogRanges(parsedExpr, t.Expr, autoDuration); ok && len(ranges) > 0 {
+					r.AddFixableErrorAt(d, p, t, message, ranges[0], fixTargetLogQLAutoRule(autoDuration))
+				} else if rng, ok := fixedLogRange(t.Expr); ok {
+					r.AddErrorAt(d, p, t, message, rng)
+				} else {
+					r.AddError(d, p, t, message)
+				}
 			}
 
 			return r
@@ -81,6 +96,87 @@ func NewTargetLogQLAutoRule() *TargetRuleFunc {
 	}
 }
 
+// fixTargetLogQLAutoRule replaces the fixed range durations of the query with $__auto. Only the
+// text between the brackets is replaced, so the rest of the query, including its variables, is
+// kept as it is.
+func fixTargetLogQLAutoRule(autoDuration time.Duration) func(Dashboard, Panel, *Target) {
+	return func(d Dashboard, _ Panel, t *Target) {
+		parsedExpr, err := parseLogQL(t.Expr, d.Templating.List)
+		if err != nil {
+			return
+		}
+		ranges, ok := fixedLogRanges(parsedExpr, t.Expr, autoDuration)
+		if !ok {
+			return
+		}
+		// Replace from the end, so the earlier ranges stay valid.
+		fixed := t.Expr
+		for i := len(ranges) - 1; i >= 0; i-- {
+			fixed = fixed[:ranges[i].Start] + "[$__auto]" + fixed[ranges[i].End:]
+		}
+		t.Expr = fixed
+	}
+}
+
+// fixedLogRanges locates the ranges of the query, e.g. [5m], whose duration is not $__auto.
+// LogQL expressions do not record their positions, so the ranges of the parsed expression are
+// matched with the brackets in the query text, in order. It fails if their numbers differ.
+func fixedLogRanges(parsedExpr syntax.Expr, expr string, autoDuration time.Duration) ([]QueryRange, bool) {
+	var intervals []time.Duration
+	Inspect(parsedExpr, func(node syntax.Expr) bool {
+		if logRange, ok := node.(*syntax.LogRangeExpr); ok {
+			intervals = append(intervals, logRange.Interval)
+		}
+		return true
+	})
+	brackets := logQLRanges(expr)
+	if len(brackets) != len(intervals) {
+		return nil, false
+	}
+
+	var ranges []QueryRange
+	for i, rng := range brackets {
+		if intervals[i] != autoDuration && !strings.Contains(expr[rng.Start:rng.End], "$__auto") {
+			ranges = append(ranges, rng)
+		}
+	}
+	return ranges, true
+}
+
+// logQLRanges locates the ranges of a LogQL query, including their brackets. Brackets in string
+// literals are skipped.
+func logQLRanges(expr string) []QueryRange {
+	var ranges []QueryRange
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '"', '\'', '`':
+			end := quotedStringEnd(expr, i)
+			if end < 0 {
+				return ranges
+			}
+			i = end - 1
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return ranges
+			}
+			ranges = append(ranges, QueryRange{Start: i, End: i + end + 1})
+			i += end
+		}
+	}
+	return ranges
+}
+
+// fixedLogRange locates the first range of the query, e.g. [5m], which does not use $__auto.
+func fixedLogRange(expr string) (QueryRange, bool) {
+	for _, rng := range logQLRanges(expr) {
+		if !strings.Contains(expr[rng.Start:rng.End], "$__auto") {
+			return rng, true
+		}
+	}
+	return QueryRange{}, false
+}
+
 func Inspect(node syntax.Expr, f func(syntax.Expr) bool) {
 	if node == nil || !f(node) {
 		return
@@ -2,6 +2,8 @@ package lint
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 // TestTargetLogQLAutoRule tests the NewTargetLogQLAutoRule function to ensure
@@ -248,3 +250,46 @@ func TestTargetLogQLAutoRule(t *testing.T) {
 		testRule(t, linter, dashboard, tc.result)
 	}
 }
+
+func TestTargetLogQLAutoRuleFix(t *testing.T) {
+	for _, tc := range []struct {
+		expr     string
+		expected string
+	}{
+		{
+			expr:     `sum(rate({job=~"$job",instance=~"$instance"}[5m]))`,
+			expected: `sum(rate({job=~"$job",instance=~"$instance"}[$__auto]))`,
+		},
+		{
+			// Filters, parsers and label filters are kept, including brackets in strings.
+			expr:     `sum by (level) (count_over_time({job="mysql"} |= "[error]" | logfmt | level=~"$level" [1h]))`,
+			expected: `sum by (level) (count_over_time({job="mysql"} |= "[error]" | logfmt | level=~"$level" [$__auto]))`,
+		},
+		{
+			expr:     "sum_over_time({job=\"mysql\"} |~ `\\[[0-9]+\\]` | unwrap duration[5m] offset 1h)",
+			expected: "sum_over_time({job=\"mysql\"} |~ `\\[[0-9]+\\]` | unwrap duration[$__auto] offset 1h)",
+		},
+		{
+			expr:     `bytes_rate({job="mysql"}[$__range]) / count_over_time({job="mysql"}[1m])`,
+			expected: `bytes_rate({job="mysql"}[$__auto]) / count_over_time({job="mysql"}[$__auto])`,
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			d := Dashboard{Title: "dashboard", Panels: []Panel{{
+				Title:   "panel",
+				Type:    "singlestat",
+				Targets: []Target{{Expr: tc.expr}},
+			}}}
+			d.Templating.List = []Template{{Type: "datasource", Query: "loki"}}
+
+			rs := ResultSet{}
+			NewTargetLogQLAutoRule().Lint(d, &rs)
+			require.Equal(t, 1, rs.AutoFix(&d))
+			require.Equal(t, tc.expected, d.Panels[0].Targets[0].Expr)
+
+			rs = ResultSet{}
+			NewTargetLogQLAutoRule().Lint(d, &rs)
+			require.Equal(t, Success, rs.MaximumSeverity())
+		})
+	}
+}
@@ -23,11 +23,19 @@ func panelHasQueries(p Panel) bool {
 // replacing eg [$__rate_interval] with [5m] so queries parse correctly.
 // We also replace various other Grafana global variables.
 func parsePromQL(expr string, variables []Template) (parser.Expr, error) {
-	expr, err := expandVariables(expr, variables)
+	node, _, err := parsePromQLMapped(expr, variables)
+	return node, err
+}
+
+// parsePromQLMapped parses like parsePromQL, and also returns the map from positions in the
+// parsed expression back to the original expression.
+func parsePromQLMapped(expr string, variables []Template) (parser.Expr, offsetMap, error) {
+	expr, m, err := expandVariablesMapped(expr, variables)
 	if err != nil {
-		return nil, fmt.Errorf("could not expand variables: %w", err)
+		return nil, nil, fmt.Errorf("could not expand variables: %w", err)
 	}
-	return parser.NewParser(parser.Options{}).ParseExpr(expr)
+	node, err := parser.NewParser(parser.Options{}).ParseExpr(expr)
+	return node, m, err
 }
 
 // NewTargetPromQLRule builds a lint rule for panels with Prometheus queries which checks:
@@ -39,6 +47,14 @@ func NewTargetPromQLRule() *TargetRuleFunc {
 	return &TargetRuleFunc{
 		name:        "target-promql-rule",
 		description: "Checks that each target uses a valid PromQL query.",
+		metadata: Metadata{
+			Category:    CategoryTarget,
+			Datasources: []string{Prometheus},
+			Severity:    Error,
+			Weight:      3,
+			DocsURL:     docsURL("target-promql-rule"),
+			Rationale:   "An invalid PromQL query fails at render time, leaving the panel empty.",
+		},
 		fn: func(d Dashboard, p Panel, t Target) TargetRuleResults {
 			r := TargetRuleResults{}
 
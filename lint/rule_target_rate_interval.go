@@ -2,9 +2,11 @@ package lint
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/promql/parser/posrange"
 )
 
 type inspector func(parser.Node, []parser.Node) error
@@ -27,6 +29,14 @@ func NewTargetRateIntervalRule() *TargetRuleFunc {
 	return &TargetRuleFunc{
 		name:        "target-rate-interval-rule",
 		description: "Checks that each target uses $__rate_interval.",
+		metadata: Metadata{
+			Category:    CategoryTarget,
+			Datasources: []string{Prometheus},
+			Severity:    Error,
+			DocsURL:     docsURL("target-rate-interval-rule"),
+			Fixable:     true,
+			Rationale:   "$__rate_interval guarantees a rate() window that always covers enough samples for the scrape interval and the current resolution, avoiding gaps and misleading spikes.",
+		},
 		fn: func(d Dashboard, p Panel, t Target) TargetRuleResults {
 			r := TargetRuleResults{}
 			if t := getTemplateDatasource(d); t == nil || t.Query != Prometheus {
@@ -39,11 +49,14 @@ func NewTargetRateIntervalRule() *TargetRuleFunc {
 				return r
 			}
 
-			expr, err := parsePromQL(t.Expr, d.Templating.List)
+			expr, offsets, err := parsePromQLMapped(t.Expr, d.Templating.List)
 			if err != nil {
 				// Invalid PromQL is another rule
 				return r
 			}
+			var problem QueryRange
+			// fixable is set for the ranges of rate and irate calls, which the fix replaces.
+			fixable := false
 			err = parser.Walk(inspector(func(node parser.Node, parents []parser.Node) error {
 				selector, ok := node.(*parser.MatrixSelector)
 				if !ok {
@@ -60,6 +73,11 @@ func NewTargetRateIntervalRule() *TargetRuleFunc {
 					// Bit weird to have a naked foo[$__rate_interval], but allow it.
 					return nil
 				}
+				// The problem is the range, e.g. [5m]
+				problem = offsets.queryRange(posrange.PositionRange{
+					Start: selector.VectorSelector.PositionRange().End,
+					End:   selector.EndPos,
+				})
 				// Now check if the parent is a rate function
 				call, ok := parents[len(parents)-1].(*parser.Call)
 				if !ok {
@@ -72,13 +90,55 @@ func NewTargetRateIntervalRule() *TargetRuleFunc {
 					return nil
 				}
 
+				fixable = true
 				return fmt.Errorf("invalid PromQL query '%s': should use $__rate_interval", t.Expr)
 			}), expr, nil)
-			if err != nil {
-				r.AddError(d, p, t, err.Error())
+			if err != nil && fixable {
+				r.AddFixableErrorAt(d, p, t, err.Error(), problem, fixTargetRateIntervalRule)
+			} else if err != nil {
+				r.AddErrorAt(d, p, t, err.Error(), problem)
 			}
 
 			return r
 		},
 	}
 }
+
+// fixTargetRateIntervalRule replaces the fixed ranges of rate and irate calls with
+// $__rate_interval. The rest of the expression, including its variables, is kept as it is.
+func fixTargetRateIntervalRule(d Dashboard, _ Panel, t *Target) {
+	expr, offsets, err := parsePromQLMapped(t.Expr, d.Templating.List)
+	if err != nil {
+		return
+	}
+	rateIntervalMagicDuration, _ := time.ParseDuration(globalVariables["__rate_interval"].(string))
+
+	var ranges []QueryRange
+	_ = parser.Walk(inspector(func(node parser.Node, parents []parser.Node) error {
+		selector, ok := node.(*parser.MatrixSelector)
+		if !ok || selector.Range == rateIntervalMagicDuration || len(parents) == 0 {
+			return nil
+		}
+		if call, ok := parents[len(parents)-1].(*parser.Call); !ok || (call.Func.Name != "rate" && call.Func.Name != "irate") {
+			return nil
+		}
+		ranges = append(ranges, offsets.queryRange(posrange.PositionRange{
+			Start: selector.VectorSelector.PositionRange().End,
+			End:   selector.EndPos,
+		}))
+		return nil
+	}), expr, nil)
+
+	// Replace from the end, so the earlier ranges stay valid.
+	fixed := t.Expr
+	for i := len(ranges) - 1; i >= 0; i-- {
+		rng := ranges[i]
+		open := strings.IndexByte(fixed[rng.Start:rng.End], '[')
+		end := strings.LastIndexByte(fixed[rng.Start:rng.End], ']')
+		if open < 0 || end < open {
+			return
+		}
+		fixed = fixed[:rng.Start+open+1] + "$__rate_interval" + fixed[rng.Start+end:]
+	}
+	t.Expr = fixed
+}
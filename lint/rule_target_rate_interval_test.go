@@ -2,6 +2,8 @@ package lint
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestTargetRateIntervalRule(t *testing.T) {
@@ -130,3 +132,68 @@ func TestTargetRateIntervalRule(t *testing.T) {
 		testRule(t, linter, dashboard, tc.result)
 	}
 }
+
+func TestTargetRateIntervalRuleFix(t *testing.T) {
+	for _, tc := range []struct {
+		expr     string
+		expected string
+	}{
+		{
+			expr:     `sum(rate(foo{job=~"$job",instance=~"$instance"}[5m]))`,
+			expected: `sum(rate(foo{job=~"$job",instance=~"$instance"}[$__rate_interval]))`,
+		},
+		{
+			expr:     `rate(foo[5m]) / irate(bar{job=~"$job"}[ 1h ])`,
+			expected: `rate(foo[$__rate_interval]) / irate(bar{job=~"$job"}[$__rate_interval])`,
+		},
+		{
+			expr:     `histogram_quantile(0.9, sum by (le) (rate(x_bucket[$__range])))`,
+			expected: `histogram_quantile(0.9, sum by (le) (rate(x_bucket[$__rate_interval])))`,
+		},
+		{
+			expr:     `rate(foo[5m] offset 1h)`,
+			expected: `rate(foo[$__rate_interval] offset 1h)`,
+		},
+		{
+			// Only rate and irate are fixed.
+			expr:     `max_over_time(foo[5m]) + rate(foo[$__rate_interval]) + rate(bar[1m])`,
+			expected: `max_over_time(foo[5m]) + rate(foo[$__rate_interval]) + rate(bar[$__rate_interval])`,
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			d := Dashboard{Title: "dashboard", Panels: []Panel{{
+				Title:   "panel",
+				Type:    "singlestat",
+				Targets: []Target{{Expr: tc.expr}},
+			}}}
+			d.Templating.List = []Template{{Type: "datasource", Query: "prometheus"}}
+
+			rs := ResultSet{}
+			NewTargetRateIntervalRule().Lint(d, &rs)
+			require.Equal(t, 1, rs.AutoFix(&d))
+			require.Equal(t, tc.expected, d.Panels[0].Targets[0].Expr)
+
+			rs = ResultSet{}
+			NewTargetRateIntervalRule().Lint(d, &rs)
+			require.Equal(t, Success, rs.MaximumSeverity())
+		})
+	}
+}
+
+func TestTargetRateIntervalRuleNotFixable(t *testing.T) {
+	d := Dashboard{Title: "dashboard", Panels: []Panel{{
+		Title:   "panel",
+		Type:    "singlestat",
+		Targets: []Target{{Expr: `rate((foo[5m]))`}},
+	}}}
+	d.Templating.List = []Template{{Type: "datasource", Query: "prometheus"}}
+
+	// Only the ranges of rate and irate calls are fixed, so the result is not fixable.
+	rs := ResultSet{}
+	NewTargetRateIntervalRule().Lint(d, &rs)
+	require.Equal(t, Result{
+		Severity: Error,
+		Message:  "Dashboard 'dashboard', panel 'panel', target idx '0' invalid PromQL query 'rate((foo[5m]))': $__rate_interval used in non-rate function",
+	}, rs.results[0].Result.Results[0].Result)
+	require.Zero(t, rs.AutoFix(&d))
+}
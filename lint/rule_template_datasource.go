@@ -12,12 +12,25 @@ func NewTemplateDatasourceRule() *DashboardRuleFunc {
 	return &DashboardRuleFunc{
 		name:        "template-datasource-rule",
 		description: "Checks that the dashboard has a templated datasource.",
+		metadata: Metadata{
+			Category:  CategoryTemplate,
+			Severity:  Error,
+			Weight:    2,
+			Fixable:   true,
+			DocsURL:   docsURL("template-datasource-rule"),
+			Rationale: "A templated data source lets the same dashboard be used against any compatible data source, for example one per environment or cluster, without editing its JSON.",
+		},
 		fn: func(d Dashboard) DashboardRuleResults {
 			r := DashboardRuleResults{}
 
 			templatedDs := d.GetTemplateByType("datasource")
 			if len(templatedDs) == 0 {
-				r.AddError(d, "does not have a templated data source")
+				if pluginType, ok := dashboardDatasourceType(d); ok {
+					r.AddFixableError(d, "does not have a templated data source", fixTemplateDatasourceRule(pluginType))
+				} else {
+					// Without a single plugin type in use, it's not clear what kind of data source to add.
+					r.AddError(d, "does not have a templated data source")
+				}
 			}
 
 			// TODO: Should there be a "Template" rule type which will iterate over all dashboard templates and execute rules?
@@ -62,6 +75,57 @@ func NewTemplateDatasourceRule() *DashboardRuleFunc {
 	}
 }
 
+// dashboardDatasourceType returns the plugin type of the data sources used by the panels and
+// targets, if they all use the same one.
+func dashboardDatasourceType(d Dashboard) (string, bool) {
+	types := map[string]struct{}{}
+	add := func(raw interface{}) {
+		ds, err := GetDataSource(raw)
+		if err != nil {
+			return
+		}
+		switch ds.Type {
+		case "", "datasource", "__expr__":
+			// Built-in Grafana data sources and expressions don't say what the dashboard queries.
+		default:
+			types[ds.Type] = struct{}{}
+		}
+	}
+	for _, p := range d.GetPanels() {
+		add(p.Datasource)
+		for _, t := range p.Targets {
+			add(t.Datasource)
+		}
+	}
+	if len(types) != 1 {
+		return "", false
+	}
+	for t := range types {
+		return t, true
+	}
+	return "", false
+}
+
+// fixTemplateDatasourceRule adds a datasource variable for the plugin type as the first variable.
+func fixTemplateDatasourceRule(pluginType string) func(*Dashboard) {
+	return func(d *Dashboard) {
+		if len(d.GetTemplateByType("datasource")) > 0 {
+			return
+		}
+		t := Template{
+			Name:     "datasource",
+			Label:    "Data source",
+			Type:     "datasource",
+			RawQuery: pluginType,
+			Query:    pluginType,
+			Current:  RawTemplateValue{},
+			Options:  []RawTemplateValue{},
+			Refresh:  1,
+		}
+		d.Templating.List = append([]Template{t}, d.Templating.List...)
+	}
+}
+
 func getTemplateDatasource(d Dashboard) *Template {
 	for _, template := range d.Templating.List {
 		if template.Type != "datasource" {
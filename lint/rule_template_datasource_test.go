@@ -2,6 +2,8 @@ package lint
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestTemplateDatasource(t *testing.T) {
@@ -318,3 +320,41 @@ func TestTemplateDatasource(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateDatasourceFix(t *testing.T) {
+	linter := NewTemplateDatasourceRule()
+	panels := func(types ...string) []Panel {
+		var ps []Panel
+		for _, ty := range types {
+			ps = append(ps, Panel{
+				Type:    panelTypeTimeSeries,
+				Targets: []Target{{Datasource: map[string]interface{}{"uid": "abc", "type": ty}}},
+			})
+		}
+		return ps
+	}
+
+	d := Dashboard{Title: "test", Panels: panels(Prometheus, Prometheus, "__expr__")}
+	testRuleWithAutofix(t, linter, &d, []Result{{
+		Severity: Fixed,
+		Message:  "Dashboard 'test' does not have a templated data source",
+	}}, true)
+	require.Equal(t, []Template{{
+		Name:     "datasource",
+		Label:    "Data source",
+		Type:     "datasource",
+		RawQuery: Prometheus,
+		Query:    Prometheus,
+		Current:  RawTemplateValue{},
+		Options:  []RawTemplateValue{},
+		Refresh:  1,
+	}}, d.Templating.List)
+
+	// With more than one plugin type it's not clear which data source to add.
+	d = Dashboard{Title: "test", Panels: panels(Prometheus, Loki)}
+	testRuleWithAutofix(t, linter, &d, []Result{{
+		Severity: Error,
+		Message:  "Dashboard 'test' does not have a templated data source",
+	}}, true)
+	require.Empty(t, d.Templating.List)
+}
@@ -0,0 +1,61 @@
+package lint
+
+import "fmt"
+
+// NewTemplateGroupByAdHocDatasourceRule builds a lint rule which checks that group by and ad hoc
+// filter variables use a datasource variable, like the panels they apply to.
+func NewTemplateGroupByAdHocDatasourceRule() *DashboardRuleFunc {
+	return &DashboardRuleFunc{
+		name:        "template-groupby-adhoc-datasource-rule",
+		description: "Checks that group by and ad hoc filter variables use the templated datasource.",
+		metadata: Metadata{
+			Category:  CategoryTemplate,
+			Severity:  Error,
+			Fixable:   true,
+			DocsURL:   docsURL("template-groupby-adhoc-datasource-rule"),
+			Rationale: "Group by and ad hoc filter variables only apply to the queries of their own data source. Pinned to a fixed data source, they stop applying to the panels when the data source variable is changed.",
+		},
+		fn: func(d Dashboard) DashboardRuleResults {
+			r := DashboardRuleResults{}
+
+			// That a templated datasource exists, is the responsibility of another rule.
+			templatedDs := d.GetTemplateByType("datasource")
+			if len(templatedDs) == 0 {
+				return r
+			}
+			availableDsUids := make(map[string]struct{}, len(templatedDs)*2)
+			for _, tds := range templatedDs {
+				availableDsUids[fmt.Sprintf("$%s", tds.Name)] = struct{}{}
+				availableDsUids[fmt.Sprintf("${%s}", tds.Name)] = struct{}{}
+			}
+
+			for _, t := range d.Templating.List {
+				kind, ok := map[string]string{"groupby": "group by", "adhoc": "ad hoc"}[t.Type]
+				if !ok {
+					continue
+				}
+				src, err := t.GetDataSource()
+				if err != nil {
+					r.AddError(d, fmt.Sprintf("%s variable '%s' has invalid datasource %v", kind, t.Name, err))
+					continue
+				}
+				if _, ok := availableDsUids[src.UID]; ok {
+					continue
+				}
+				message := fmt.Sprintf("%s variable '%s' should use a templated datasource, uses '%s'", kind, t.Name, src.UID)
+				if src.UID == "" {
+					message = fmt.Sprintf("%s variable '%s' should use a templated datasource, uses the default datasource", kind, t.Name)
+				}
+				if ds := matchingTemplatedDatasource(templatedDs, src); ds != nil {
+					r.AddFixableError(d, message, fixTemplate(t.Name, func(t *Template) {
+						t.Datasource = templatedDatasource(t.Datasource, *ds)
+					}))
+				} else {
+					// Without a single matching templated datasource, it's not clear what to use.
+					r.AddWarning(d, message)
+				}
+			}
+			return r
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateGroupByAdHocDatasourceRule(t *testing.T) {
+	linter := NewTemplateGroupByAdHocDatasourceRule()
+	prometheus := Template{Type: "datasource", Name: "datasource", Query: "prometheus"}
+	loki := Template{Type: "datasource", Name: "logs", Query: "loki"}
+
+	for _, tc := range []struct {
+		name      string
+		templates []Template
+		result    []Result
+	}{
+		{
+			name: "templated",
+			templates: []Template{
+				prometheus,
+				{Type: "groupby", Name: "groupby", Datasource: map[string]interface{}{"type": "prometheus", "uid": "$datasource"}},
+				{Type: "adhoc", Name: "filters", Datasource: "${datasource}"},
+			},
+			result: []Result{ResultSuccess},
+		},
+		{
+			name: "no datasource variable",
+			templates: []Template{
+				{Type: "groupby", Name: "groupby", Datasource: "prom-1"},
+			},
+			result: []Result{ResultSuccess},
+		},
+		{
+			name: "fixed and default",
+			templates: []Template{
+				prometheus,
+				{Type: "groupby", Name: "groupby", Datasource: map[string]interface{}{"type": "prometheus", "uid": "prom-1"}},
+				{Type: "adhoc", Name: "filters"},
+			},
+			result: []Result{
+				{Severity: Error, Message: "Dashboard 'test' group by variable 'groupby' should use a templated datasource, uses 'prom-1'"},
+				{Severity: Error, Message: "Dashboard 'test' ad hoc variable 'filters' should use a templated datasource, uses the default datasource"},
+			},
+		},
+		{
+			name: "ambiguous",
+			templates: []Template{
+				prometheus,
+				loki,
+				{Type: "adhoc", Name: "filters", Datasource: "prom-1"},
+			},
+			result: []Result{
+				{Severity: Warning, Message: "Dashboard 'test' ad hoc variable 'filters' should use a templated datasource, uses 'prom-1'"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Dashboard{Title: "test"}
+			d.Templating.List = tc.templates
+			testMultiResultRule(t, linter, d, tc.result)
+		})
+	}
+}
+
+func TestTemplateGroupByAdHocDatasourceFix(t *testing.T) {
+	d := Dashboard{Title: "test"}
+	d.Templating.List = []Template{
+		{Type: "datasource", Name: "datasource", Query: "prometheus"},
+		{Type: "groupby", Name: "groupby", Datasource: map[string]interface{}{"type": "prometheus", "uid": "prom-1"}},
+		{Type: "adhoc", Name: "filters"},
+	}
+
+	rs := ResultSet{}
+	NewTemplateGroupByAdHocDatasourceRule().Lint(d, &rs)
+	rs.AutoFix(&d)
+	require.Equal(t, Fixed, rs.MaximumSeverity())
+	require.Equal(t, map[string]interface{}{"type": "prometheus", "uid": "$datasource"}, d.Templating.List[1].Datasource)
+	require.Equal(t, map[string]interface{}{"type": "prometheus", "uid": "$datasource"}, d.Templating.List[2].Datasource)
+}
@@ -4,6 +4,14 @@ func NewTemplateInstanceRule() *DashboardRuleFunc {
 	return &DashboardRuleFunc{
 		name:        "template-instance-rule",
 		description: "Checks that the dashboard has a templated instance.",
+		metadata: Metadata{
+			Category:    CategoryTemplate,
+			Datasources: []string{Prometheus},
+			Severity:    Error,
+			Fixable:     true,
+			DocsURL:     docsURL("template-instance-rule"),
+			Rationale:   "Every Prometheus series carries an instance label. A multi-select instance variable lets users narrow every panel down to individual targets.",
+		},
 		fn: func(d Dashboard) DashboardRuleResults {
 			r := DashboardRuleResults{}
 
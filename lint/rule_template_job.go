@@ -2,7 +2,11 @@ package lint
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -11,6 +15,14 @@ func NewTemplateJobRule() *DashboardRuleFunc {
 	return &DashboardRuleFunc{
 		name:        "template-job-rule",
 		description: "Checks that the dashboard has a templated job.",
+		metadata: Metadata{
+			Category:    CategoryTemplate,
+			Datasources: []string{Prometheus},
+			Severity:    Error,
+			Fixable:     true,
+			DocsURL:     docsURL("template-job-rule"),
+			Rationale:   "Every Prometheus series carries a job label. A multi-select job variable lets users narrow every panel down to the jobs they care about.",
+		},
 		fn: func(d Dashboard) DashboardRuleResults {
 			r := DashboardRuleResults{}
 
@@ -28,7 +40,13 @@ func NewTemplateJobRule() *DashboardRuleFunc {
 func checkTemplate(d Dashboard, name string, r *DashboardRuleResults) {
 	t := getTemplate(d, name)
 	if t == nil {
-		r.AddError(d, fmt.Sprintf("is missing the %s template", name))
+		message := fmt.Sprintf("is missing the %s template", name)
+		if getAcceptedTemplateDatasource(d) != nil {
+			r.AddFixableError(d, message, fixMissingTemplate(name))
+		} else {
+			// The variable would use a datasource it is not allowed to.
+			r.AddError(d, message)
+		}
 		return
 	}
 
@@ -41,7 +59,16 @@ func checkTemplate(d Dashboard, name string, r *DashboardRuleResults) {
 
 	srcUid := src.UID
 	if srcUid != "$datasource" && srcUid != "${datasource}" && srcUid != "$prometheus_datasource" && srcUid != "${prometheus_datasource}" {
-		r.AddError(d, fmt.Sprintf("%s template should use datasource '$datasource', is currently '%s'", name, srcUid))
+		message := fmt.Sprintf("%s template should use datasource '$datasource', is currently '%s'", name, srcUid)
+		// The fix is only offered if it makes the template pass, so for a variable with one of the
+		// names above.
+		if ds := getAcceptedTemplateDatasource(d); ds != nil {
+			r.AddFixableError(d, message, fixTemplate(name, func(t *Template) {
+				t.Datasource = templatedDatasource(t.Datasource, *ds)
+			}))
+		} else {
+			r.AddError(d, message)
+		}
 	}
 
 	if t.Type != targetTypeQuery {
@@ -52,15 +79,30 @@ func checkTemplate(d Dashboard, name string, r *DashboardRuleResults) {
 	labelTitle := titleCaser.String(name)
 
 	if t.Label != labelTitle {
-		r.AddWarning(d, fmt.Sprintf("%s template should be a labeled '%s', is currently '%s'", name, labelTitle, t.Label))
+		r.AddFixableWarning(d, fmt.Sprintf("%s template should be a labeled '%s', is currently '%s'", name, labelTitle, t.Label),
+			fixTemplate(name, func(t *Template) { t.Label = labelTitle }))
 	}
 
 	if !t.Multi {
-		r.AddError(d, fmt.Sprintf("%s template should be a multi select", name))
+		r.AddFixableError(d, fmt.Sprintf("%s template should be a multi select", name),
+			fixTemplate(name, func(t *Template) { t.Multi = true }))
 	}
 
 	if t.AllValue != ".+" {
-		r.AddError(d, fmt.Sprintf("%s template allValue should be '.+', is currently '%s'", name, t.AllValue))
+		r.AddFixableError(d, fmt.Sprintf("%s template allValue should be '.+', is currently '%s'", name, t.AllValue),
+			fixTemplate(name, func(t *Template) { t.AllValue = ".+" }))
+	}
+}
+
+// fixTemplate applies fix to the variable with the name.
+func fixTemplate(name string, fix func(*Template)) func(*Dashboard) {
+	return func(d *Dashboard) {
+		for i := range d.Templating.List {
+			if d.Templating.List[i].Name == name {
+				fix(&d.Templating.List[i])
+				return
+			}
+		}
 	}
 }
 
@@ -72,3 +114,111 @@ func getTemplate(d Dashboard, name string) *Template {
 	}
 	return nil
 }
+
+// fixMissingTemplate adds a job or instance variable querying the datasource variable. Job is
+// added after the datasource variables and instance after job, which it is filtered by.
+func fixMissingTemplate(name string) func(*Dashboard) {
+	return func(d *Dashboard) {
+		ds := getAcceptedTemplateDatasource(*d)
+		if ds == nil || getTemplate(*d, name) != nil {
+			return
+		}
+
+		var selector []string
+		pos := 0
+		for i, t := range d.Templating.List {
+			if t.Type == "datasource" {
+				pos = i + 1
+			}
+		}
+		if name == "instance" {
+			for i, t := range d.Templating.List {
+				if t.Name == "job" {
+					pos = i + 1
+					selector = append(selector, `job=~"$job"`)
+				}
+			}
+		}
+
+		query := fmt.Sprintf("label_values(%s, %s)", metricSelector(dashboardMetrics(*d), selector), name)
+		t := Template{
+			Name:       name,
+			Label:      cases.Title(language.English).String(name),
+			Type:       targetTypeQuery,
+			RawQuery:   query,
+			Query:      query,
+			Datasource: map[string]interface{}{"type": ds.Query, "uid": fmt.Sprintf("$%s", ds.Name)},
+			Multi:      true,
+			IncludeAll: true,
+			AllValue:   ".+",
+			Current:    RawTemplateValue{},
+			Options:    []RawTemplateValue{},
+			Refresh:    2,
+		}
+
+		list := make([]Template, 0, len(d.Templating.List)+1)
+		list = append(list, d.Templating.List[:pos]...)
+		list = append(list, t)
+		d.Templating.List = append(list, d.Templating.List[pos:]...)
+	}
+}
+
+// dashboardMetrics returns the sorted names of the metrics selected by the PromQL targets.
+func dashboardMetrics(d Dashboard) []string {
+	seen := map[string]struct{}{}
+	for _, p := range d.GetPanels() {
+		for _, t := range p.Targets {
+			if t.Expr == "" || isLokiTarget(d, t) {
+				continue
+			}
+			node, err := parsePromQL(t.Expr, d.Templating.List)
+			if err != nil {
+				continue
+			}
+			parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+				if vs, ok := n.(*parser.VectorSelector); ok {
+					for _, m := range vs.LabelMatchers {
+						if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+							seen[m.Value] = struct{}{}
+						}
+					}
+				}
+				return nil
+			})
+		}
+	}
+	metrics := make([]string, 0, len(seen))
+	for m := range seen {
+		metrics = append(metrics, m)
+	}
+	sort.Strings(metrics)
+	return metrics
+}
+
+// metricSelector returns a selector for any of the metrics with the extra matchers, falling back
+// to the up metric which every scraped target has.
+func metricSelector(metrics []string, matchers []string) string {
+	switch len(metrics) {
+	case 0:
+		metrics = []string{"up"}
+	case 1:
+	default:
+		matchers = append([]string{fmt.Sprintf(`__name__=~"%s"`, strings.Join(metrics, "|"))}, matchers...)
+		return fmt.Sprintf("{%s}", strings.Join(matchers, ", "))
+	}
+	if len(matchers) == 0 {
+		return metrics[0]
+	}
+	return fmt.Sprintf("%s{%s}", metrics[0], strings.Join(matchers, ", "))
+}
+
+// getAcceptedTemplateDatasource returns the datasource variable the job and instance templates
+// may use, if there is one.
+func getAcceptedTemplateDatasource(d Dashboard) *Template {
+	for _, t := range d.GetTemplateByType("datasource") {
+		if t.Name == "datasource" || t.Name == "prometheus_datasource" {
+			return &t
+		}
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package lint
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestJobTemplate(t *testing.T) {
@@ -156,3 +158,141 @@ func TestJobTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestMissingTemplateFix(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		exprs    []string
+		job      string
+		instance string
+	}{
+		{
+			name:     "no metrics",
+			job:      `label_values(up, job)`,
+			instance: `label_values(up{job=~"$job"}, instance)`,
+		},
+		{
+			name:     "one metric",
+			exprs:    []string{`sum(rate(http_requests_total[$__rate_interval]))`},
+			job:      `label_values(http_requests_total, job)`,
+			instance: `label_values(http_requests_total{job=~"$job"}, instance)`,
+		},
+		{
+			name:     "several metrics",
+			exprs:    []string{`up{job="a"} * on(instance) node_load1`, `{__name__="go_goroutines"}`},
+			job:      `label_values({__name__=~"go_goroutines|node_load1|up"}, job)`,
+			instance: `label_values({__name__=~"go_goroutines|node_load1|up", job=~"$job"}, instance)`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Dashboard{Title: "test"}
+			d.Templating.List = []Template{
+				{Name: "datasource", Type: "datasource", Query: Prometheus},
+				{Name: "interval", Type: "interval", Query: "1m,5m"},
+			}
+			for _, expr := range tc.exprs {
+				d.Panels = append(d.Panels, Panel{Type: panelTypeTimeSeries, Targets: []Target{{Expr: expr}}})
+			}
+
+			rs := ResultSet{}
+			for _, rule := range []Rule{NewTemplateJobRule(), NewTemplateInstanceRule()} {
+				rule.Lint(d, &rs)
+			}
+			require.Equal(t, 2, rs.AutoFix(&d))
+
+			expected := func(name, label, query string) Template {
+				return Template{
+					Name:       name,
+					Label:      label,
+					Type:       "query",
+					RawQuery:   query,
+					Query:      query,
+					Datasource: map[string]interface{}{"type": Prometheus, "uid": "$datasource"},
+					Multi:      true,
+					IncludeAll: true,
+					AllValue:   ".+",
+					Current:    RawTemplateValue{},
+					Options:    []RawTemplateValue{},
+					Refresh:    2,
+				}
+			}
+			require.Equal(t, []Template{
+				d.Templating.List[0],
+				expected("job", "Job", tc.job),
+				expected("instance", "Instance", tc.instance),
+				d.Templating.List[3],
+			}, d.Templating.List)
+			require.Equal(t, "interval", d.Templating.List[3].Name)
+
+			// The fixed variables pass the template rules.
+			for _, rule := range []Rule{NewTemplateJobRule(), NewTemplateInstanceRule(), NewTemplateLabelPromQLRule()} {
+				testRule(t, rule, d, ResultSuccess)
+			}
+		})
+	}
+}
+
+func TestTemplateFix(t *testing.T) {
+	dashboard := func() Dashboard {
+		d := Dashboard{Title: "test"}
+		d.Templating.List = []Template{
+			{Name: "datasource", Type: "datasource", Query: Prometheus},
+			{Name: "job", Type: "query", Datasource: map[string]interface{}{"uid": "foo", "type": Prometheus}, Label: "job"},
+		}
+		return d
+	}
+
+	rs := ResultSet{}
+	NewTemplateJobRule().Lint(dashboard(), &rs)
+	results := rs.results[0].Result.Results
+	require.Len(t, results, 4)
+
+	// Each fix changes one property, so they can be applied independently.
+	for i, fixed := range []Template{
+		{Name: "job", Type: "query", Datasource: map[string]interface{}{"uid": "$datasource", "type": Prometheus}, Label: "job"},
+		{Name: "job", Type: "query", Datasource: map[string]interface{}{"uid": "foo", "type": Prometheus}, Label: "Job"},
+		{Name: "job", Type: "query", Datasource: map[string]interface{}{"uid": "foo", "type": Prometheus}, Label: "job", Multi: true},
+		{Name: "job", Type: "query", Datasource: map[string]interface{}{"uid": "foo", "type": Prometheus}, Label: "job", AllValue: ".+"},
+	} {
+		t.Run(results[i].Message, func(t *testing.T) {
+			d := dashboard()
+			require.NotNil(t, results[i].Fix)
+			results[i].Fix(&d)
+			require.Equal(t, fixed, d.Templating.List[1])
+		})
+	}
+
+	d := dashboard()
+	require.Equal(t, 4, rs.AutoFix(&d))
+	testRule(t, NewTemplateJobRule(), d, ResultSuccess)
+}
+
+func TestTemplateFixStringDatasource(t *testing.T) {
+	d := Dashboard{Title: "test"}
+	d.Templating.List = []Template{
+		{Name: "datasource", Type: "datasource", Query: Prometheus},
+		{Name: "instance", Type: "query", Datasource: "foo", Label: "Instance", Multi: true, AllValue: ".+"},
+	}
+	testRuleWithAutofix(t, NewTemplateInstanceRule(), &d, []Result{{
+		Severity: Fixed,
+		Message:  "Dashboard 'test' instance template should use datasource '$datasource', is currently 'foo'",
+	}}, true)
+	// The string form of the datasource is kept.
+	require.Equal(t, "$datasource", d.Templating.List[1].Datasource)
+}
+
+func TestTemplateFixNamedDatasource(t *testing.T) {
+	d := Dashboard{Title: "test"}
+	d.Templating.List = []Template{
+		{Name: "prom", Type: "datasource", Query: Prometheus},
+		{Name: "job", Type: "query", Datasource: "${prom}", Label: "Job", Multi: true, AllValue: ".+"},
+	}
+
+	// Only the datasource and prometheus_datasource variables are accepted, so the template cannot
+	// be fixed to use another one.
+	testRuleWithAutofix(t, NewTemplateJobRule(), &d, []Result{{
+		Severity: Error,
+		Message:  "Dashboard 'test' job template should use datasource '$datasource', is currently '${prom}'",
+	}}, true)
+	require.Equal(t, "${prom}", d.Templating.List[1].Datasource)
+}
@@ -43,6 +43,13 @@ func NewTemplateLabelPromQLRule() *DashboardRuleFunc {
 	return &DashboardRuleFunc{
 		name:        "template-label-promql-rule",
 		description: "Checks that the dashboard templated labels have proper PromQL expressions.",
+		metadata: Metadata{
+			Category:    CategoryTemplate,
+			Datasources: []string{Prometheus},
+			Severity:    Error,
+			DocsURL:     docsURL("template-label-promql-rule"),
+			Rationale:   "Query variables are populated by a Prometheus variable function such as label_values(). An invalid function or PromQL expression leaves the variable, and every panel using it, empty.",
+		},
 		fn: func(d Dashboard) DashboardRuleResults {
 			r := DashboardRuleResults{}
 
@@ -8,6 +8,13 @@ func NewTemplateOnTimeRangeReloadRule() *DashboardRuleFunc {
 	return &DashboardRuleFunc{
 		name:        "template-on-time-change-reload-rule",
 		description: "Checks that the dashboard template variables are configured to reload on time change.",
+		metadata: Metadata{
+			Category:  CategoryTemplate,
+			Severity:  Error,
+			Fixable:   true,
+			DocsURL:   docsURL("template-on-time-change-reload-rule"),
+			Rationale: "Variables which only refresh on dashboard load go stale when the time range changes, and may offer values which no longer exist.",
+		},
 		fn: func(d Dashboard) DashboardRuleResults {
 			r := DashboardRuleResults{}
 
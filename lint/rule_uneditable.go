@@ -4,6 +4,13 @@ func NewUneditableRule() *DashboardRuleFunc {
 	return &DashboardRuleFunc{
 		name:        "uneditable-dashboard",
 		description: "Checks that the dashboard is not editable.",
+		metadata: Metadata{
+			Category:  CategoryDashboard,
+			Severity:  Error,
+			Fixable:   true,
+			DocsURL:   docsURL("uneditable-dashboard"),
+			Rationale: "Dashboards provisioned from code should be changed in code. Edits made in the UI are lost on the next deployment.",
+		},
 		fn: func(d Dashboard) DashboardRuleResults {
 			r := DashboardRuleResults{}
 			if d.Editable {
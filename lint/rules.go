@@ -1,22 +1,38 @@
 package lint
 
+import "context"
+
 type Rule interface {
 	Description() string
 	Name() string
 	Lint(Dashboard, *ResultSet)
 }
 
+// ContextRule is implemented by rules which can be cancelled, such as rule plugins.
+type ContextRule interface {
+	Rule
+	LintContext(context.Context, Dashboard, *ResultSet)
+}
+
 type DashboardRuleFunc struct {
 	name, description string
+	metadata          Metadata
 	fn                func(Dashboard) DashboardRuleResults
 }
 
 func NewDashboardRuleFunc(name, description string, fn func(Dashboard) DashboardRuleResults) Rule {
-	return &DashboardRuleFunc{name, description, fn}
+	return &DashboardRuleFunc{name: name, description: description, fn: fn}
 }
 
 func (f DashboardRuleFunc) Name() string        { return f.name }
 func (f DashboardRuleFunc) Description() string { return f.description }
+func (f DashboardRuleFunc) Metadata() Metadata {
+	m := f.metadata
+	if m.Category == "" {
+		m.Category = CategoryDashboard
+	}
+	return m
+}
 func (f DashboardRuleFunc) Lint(d Dashboard, s *ResultSet) {
 	dashboardResults := f.fn(d).Results
 	if len(dashboardResults) == 0 {
@@ -51,19 +67,26 @@ func (f DashboardRuleFunc) Lint(d Dashboard, s *ResultSet) {
 
 type PanelRuleFunc struct {
 	name, description string
+	metadata          Metadata
 	fn                func(Dashboard, Panel) PanelRuleResults
 }
 
 func NewPanelRuleFunc(name, description string, fn func(Dashboard, Panel) PanelRuleResults) Rule {
-	return &PanelRuleFunc{name, description, fn}
+	return &PanelRuleFunc{name: name, description: description, fn: fn}
 }
 
 func (f PanelRuleFunc) Name() string        { return f.name }
 func (f PanelRuleFunc) Description() string { return f.description }
+func (f PanelRuleFunc) Metadata() Metadata {
+	m := f.metadata
+	if m.Category == "" {
+		m.Category = CategoryPanel
+	}
+	return m
+}
 func (f PanelRuleFunc) Lint(d Dashboard, s *ResultSet) {
-	for pi, p := range d.GetPanels() {
-		p := p   // capture loop variable
-		pi := pi // capture loop variable
+	for _, p := range d.GetPanels() {
+		p := p // capture loop variable
 		var rr []FixableResult
 
 		panelResults := f.fn(d, p).Results
@@ -76,7 +99,7 @@ func (f PanelRuleFunc) Lint(d Dashboard, s *ResultSet) {
 		for _, r := range panelResults {
 			var fix func(*Dashboard)
 			if r.Fix != nil {
-				fix = fixPanel(pi, r)
+				fix = fixPanel(p.pointer, r)
 			}
 			rr = append(rr, FixableResult{
 				Result: Result{
@@ -96,29 +119,37 @@ func (f PanelRuleFunc) Lint(d Dashboard, s *ResultSet) {
 	}
 }
 
-func fixPanel(pi int, r PanelResult) func(dashboard *Dashboard) {
+// fixPanel applies the fix to the panel at the JSON pointer, wherever it is nested.
+func fixPanel(pointer string, r PanelResult) func(dashboard *Dashboard) {
 	return func(dashboard *Dashboard) {
-		p := dashboard.GetPanels()[pi]
-		r.Fix(*dashboard, &p)
-		dashboard.Panels[pi] = p
+		if p := dashboard.panelAt(pointer); p != nil {
+			r.Fix(*dashboard, p)
+		}
 	}
 }
 
 type TargetRuleFunc struct {
 	name, description string
+	metadata          Metadata
 	fn                func(Dashboard, Panel, Target) TargetRuleResults
 }
 
 func NewTargetRuleFunc(name, description string, fn func(Dashboard, Panel, Target) TargetRuleResults) Rule {
-	return &TargetRuleFunc{name, description, fn}
+	return &TargetRuleFunc{name: name, description: description, fn: fn}
 }
 
 func (f TargetRuleFunc) Name() string        { return f.name }
 func (f TargetRuleFunc) Description() string { return f.description }
+func (f TargetRuleFunc) Metadata() Metadata {
+	m := f.metadata
+	if m.Category == "" {
+		m.Category = CategoryTarget
+	}
+	return m
+}
 func (f TargetRuleFunc) Lint(d Dashboard, s *ResultSet) {
-	for pi, p := range d.GetPanels() {
-		p := p   // capture loop variable
-		pi := pi // capture loop variable
+	for _, p := range d.GetPanels() {
+		p := p // capture loop variable
 		for ti, t := range p.Targets {
 			t := t   // capture loop variable
 			ti := ti // capture loop variable
@@ -134,14 +165,15 @@ func (f TargetRuleFunc) Lint(d Dashboard, s *ResultSet) {
 			for _, r := range targetResults {
 				var fix func(*Dashboard)
 				if r.Fix != nil {
-					fix = fixTarget(pi, ti, r)
+					fix = fixTarget(p.pointer, ti, r)
 				}
 				rr = append(rr, FixableResult{
 					Result: Result{
 						Severity: r.Severity,
 						Message:  r.Message,
 					},
-					Fix: fix,
+					Fix:   fix,
+					Range: r.Range,
 				})
 			}
 			s.AddResult(ResultContext{
@@ -155,13 +187,13 @@ func (f TargetRuleFunc) Lint(d Dashboard, s *ResultSet) {
 	}
 }
 
-func fixTarget(pi int, ti int, r TargetResult) func(dashboard *Dashboard) {
+// fixTarget applies the fix to a target of the panel at the JSON pointer, wherever the panel
+// is nested.
+func fixTarget(pointer string, ti int, r TargetResult) func(dashboard *Dashboard) {
 	return func(dashboard *Dashboard) {
-		p := dashboard.GetPanels()[pi]
-		t := p.Targets[ti]
-		r.Fix(*dashboard, p, &t)
-		p.Targets[ti] = t
-		dashboard.Panels[pi] = p
+		if p := dashboard.panelAt(pointer); p != nil && ti < len(p.Targets) {
+			r.Fix(*dashboard, *p, &p.Targets[ti])
+		}
 	}
 }
 
@@ -178,6 +210,7 @@ func NewRuleSet() RuleSet {
 			NewTemplateInstanceRule(),
 			NewTemplateLabelPromQLRule(),
 			NewTemplateOnTimeRangeReloadRule(),
+			NewTemplateGroupByAdHocDatasourceRule(),
 			NewPanelDatasourceRule(),
 			NewPanelTitleDescriptionRule(),
 			NewPanelUnitsRule(),
@@ -189,7 +222,10 @@ func NewRuleSet() RuleSet {
 			NewTargetJobRule(),
 			NewTargetInstanceRule(),
 			NewTargetCounterAggRule(),
+			NewTargetGroupByRule(),
 			NewUneditableRule(),
+			NewDashboardLayoutRule(),
+			NewLibraryPanelRule(),
 		},
 	}
 }
@@ -203,10 +239,22 @@ func (s *RuleSet) Add(r Rule) {
 }
 
 func (s *RuleSet) Lint(dashboards []Dashboard) (*ResultSet, error) {
+	return s.LintContext(context.Background(), dashboards)
+}
+
+// LintContext lints the dashboards like Lint, but stops once the context is done.
+func (s *RuleSet) LintContext(ctx context.Context, dashboards []Dashboard) (*ResultSet, error) {
 	resSet := &ResultSet{}
 	for _, d := range dashboards {
 		for _, r := range s.rules {
-			r.Lint(d, resSet)
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if cr, ok := r.(ContextRule); ok {
+				cr.LintContext(ctx, d, resSet)
+			} else {
+				r.Lint(d, resSet)
+			}
 		}
 	}
 	return resSet, nil
@@ -99,3 +99,66 @@ func TestFixableRules(t *testing.T) {
 
 	assert.Equal(t, "Sample dashboard fixed-once fixed-twice", dashboard.Title)
 }
+
+func TestFixNestedPanels(t *testing.T) {
+	dashboard, err := lint.NewDashboard([]byte(`{
+		"title": "nested",
+		"rows": [
+			{ "panels": [
+				{ "id": 1, "title": "legacy a", "targets": [ { "expr": "a" } ] },
+				{ "id": 2, "title": "legacy b", "targets": [ { "expr": "b" }, { "expr": "c" } ] }
+			] }
+		],
+		"panels": [
+			{ "id": 3, "title": "top", "targets": [ { "expr": "d" } ] },
+			{ "id": 4, "type": "row", "title": "collapsed", "collapsed": true, "panels": [
+				{ "id": 5, "title": "nested", "targets": [ { "expr": "e" } ] }
+			] }
+		]
+	}`))
+	assert.NoError(t, err)
+
+	rules := lint.RuleSet{}
+	rules.Add(lint.NewPanelRuleFunc(
+		"test-fixable-panel-rule", "Test fixable panel rule",
+		func(d lint.Dashboard, p lint.Panel) lint.PanelRuleResults {
+			return lint.PanelRuleResults{Results: []lint.PanelResult{{
+				Result: lint.Result{Severity: lint.Error, Message: "not fixed"},
+				Fix: func(d lint.Dashboard, p *lint.Panel) {
+					p.Title += " fixed"
+				},
+			}}}
+		},
+	))
+	rules.Add(lint.NewTargetRuleFunc(
+		"test-fixable-target-rule", "Test fixable target rule",
+		func(d lint.Dashboard, p lint.Panel, tg lint.Target) lint.TargetRuleResults {
+			return lint.TargetRuleResults{Results: []lint.TargetResult{{
+				Result: lint.Result{Severity: lint.Error, Message: "not fixed"},
+				Fix: func(d lint.Dashboard, p lint.Panel, tg *lint.Target) {
+					tg.Expr = "fixed_" + tg.Expr
+				},
+			}}}
+		},
+	))
+
+	results, err := rules.Lint([]lint.Dashboard{dashboard})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, results.AutoFix(&dashboard))
+
+	assert.Len(t, dashboard.Rows, 1)
+	assert.Len(t, dashboard.Rows[0].Panels, 2)
+	assert.Len(t, dashboard.Panels, 2)
+	assert.Len(t, dashboard.Panels[1].Panels, 1)
+
+	assert.Equal(t, "legacy a fixed", dashboard.Rows[0].Panels[0].Title)
+	assert.Equal(t, "fixed_a", dashboard.Rows[0].Panels[0].Targets[0].Expr)
+	assert.Equal(t, "legacy b fixed", dashboard.Rows[0].Panels[1].Title)
+	assert.Equal(t, "fixed_b", dashboard.Rows[0].Panels[1].Targets[0].Expr)
+	assert.Equal(t, "fixed_c", dashboard.Rows[0].Panels[1].Targets[1].Expr)
+	assert.Equal(t, "top fixed", dashboard.Panels[0].Title)
+	assert.Equal(t, "fixed_d", dashboard.Panels[0].Targets[0].Expr)
+	assert.Equal(t, "collapsed fixed", dashboard.Panels[1].Title)
+	assert.Equal(t, "nested fixed", dashboard.Panels[1].Panels[0].Title)
+	assert.Equal(t, "fixed_e", dashboard.Panels[1].Panels[0].Targets[0].Expr)
+}
@@ -0,0 +1,67 @@
+package lint
+
+import "math"
+
+// Scores returns the quality score of each dashboard, by the key of dashboardKey. A score is
+// between 0 and 100.
+// Every rule which ran for the dashboard contributes its metadata weight, scaled by the share
+// of its results which passed: errors count as failed, warnings as half failed, and results
+// which were fixed or excluded as passed.
+func (rs *ResultSet) Scores() map[string]float64 {
+	type ruleScore struct {
+		weight, failed, total float64
+	}
+	byDashboard := map[string]map[string]*ruleScore{}
+	for _, rc := range rs.results {
+		dashboard := dashboardKey(rc.Dashboard)
+		if byDashboard[dashboard] == nil {
+			byDashboard[dashboard] = map[string]*ruleScore{}
+		}
+		rule := byDashboard[dashboard][rc.Rule.Name()]
+		if rule == nil {
+			rule = &ruleScore{weight: GetMetadata(rc.Rule).Weight}
+			byDashboard[dashboard][rc.Rule.Name()] = rule
+		}
+		for _, r := range rc.Result.Results {
+			rule.total++
+			switch r.Severity {
+			case Error:
+				rule.failed++
+			case Warning:
+				rule.failed += 0.5
+			}
+		}
+	}
+
+	scores := make(map[string]float64, len(byDashboard))
+	for dashboard, rules := range byDashboard {
+		var weights, passed float64
+		for _, rule := range rules {
+			if rule.total == 0 {
+				continue
+			}
+			weights += rule.weight
+			passed += rule.weight * (1 - rule.failed/rule.total)
+		}
+		score := 100.0
+		if weights > 0 {
+			score = math.Round(1000*passed/weights) / 10
+		}
+		scores[dashboard] = score
+	}
+	return scores
+}
+
+// dashboardKey identifies a dashboard in the summary of a lint run: by where it was read from,
+// or by its UID if that is not known, as titles need not be unique. The title is the last resort.
+func dashboardKey(d *Dashboard) string {
+	switch {
+	case d == nil:
+		return ""
+	case d.Source != "":
+		return d.Source
+	case d.UID != "":
+		return d.UID
+	}
+	return d.Title
+}
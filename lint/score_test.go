@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScores(t *testing.T) {
+	heavy := &DashboardRuleFunc{name: "heavy", metadata: Metadata{Weight: 3}}
+	light := &DashboardRuleFunc{name: "light"}
+	results := func(severities ...Severity) RuleResults {
+		var rr RuleResults
+		for _, s := range severities {
+			rr.Results = append(rr.Results, FixableResult{Result: Result{Severity: s}})
+		}
+		return rr
+	}
+	a := &Dashboard{Title: "a"}
+	b := &Dashboard{Title: "b"}
+	c := &Dashboard{Title: "c"}
+
+	rs := ResultSet{}
+	// a fails the heavy rule on half of its results, and passes the light one.
+	rs.AddResult(ResultContext{Rule: heavy, Dashboard: a, Result: results(Error, Success)})
+	rs.AddResult(ResultContext{Rule: light, Dashboard: a, Result: results(Quiet)})
+	// b has a warning for the heavy rule, and fixed or excluded results for the light one.
+	rs.AddResult(ResultContext{Rule: heavy, Dashboard: b, Result: results(Warning)})
+	rs.AddResult(ResultContext{Rule: light, Dashboard: b, Result: results(Fixed, Exclude)})
+	// c fails every rule.
+	rs.AddResult(ResultContext{Rule: heavy, Dashboard: c, Result: results(Error)})
+	rs.AddResult(ResultContext{Rule: light, Dashboard: c, Result: results(Error, Error)})
+
+	require.Equal(t, map[string]float64{
+		"a": 62.5, // (3 * 0.5 + 1) / 4
+		"b": 62.5, // (3 * 0.5 + 1) / 4
+		"c": 0,
+	}, rs.Scores())
+}
+
+func TestScoresSameTitle(t *testing.T) {
+	rule := &DashboardRuleFunc{name: "rule"}
+	rs := ResultSet{}
+	// Dashboards are told apart by source, or else UID, as titles need not be unique.
+	rs.AddResult(ResultContext{Rule: rule, Dashboard: &Dashboard{Title: "a", Source: "a.json"}, Result: RuleResults{[]FixableResult{{Result: Result{Severity: Error}}}}})
+	rs.AddResult(ResultContext{Rule: rule, Dashboard: &Dashboard{Title: "a", UID: "a-2"}, Result: RuleResults{[]FixableResult{{Result: ResultSuccess}}}})
+
+	require.Equal(t, map[string]float64{"a.json": 0, "a-2": 100}, rs.Scores())
+	sum := rs.Summary()
+	require.Equal(t, map[string]string{"a.json": "a", "a-2": "a"}, sum.Titles)
+	require.Equal(t, 1, sum.Dashboards["a.json"].Error)
+	require.Equal(t, 1, sum.Dashboards["a-2"].OK)
+}
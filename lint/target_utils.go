@@ -2,6 +2,8 @@ package lint
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/prometheus/prometheus/model/labels"
 )
@@ -30,3 +32,198 @@ func checkForMatcher(selector []*labels.Matcher, name string, ty labels.MatchTyp
 
 	return result
 }
+
+// selectorMatcher is a label matcher in the text of a selector, e.g. job=~"$job".
+type selectorMatcher struct {
+	// start and end locate the matcher in the selector body.
+	start, end int
+	matcher    *labels.Matcher
+}
+
+var matchTypes = map[string]labels.MatchType{
+	"=":  labels.MatchEqual,
+	"!=": labels.MatchNotEqual,
+	"=~": labels.MatchRegexp,
+	"!~": labels.MatchNotRegexp,
+}
+
+// parseSelectorMatchers parses the body of a selector, between its braces, without expanding
+// variables. It returns false if the body is not a list of matchers.
+func parseSelectorMatchers(body string) ([]selectorMatcher, bool) {
+	var matchers []selectorMatcher
+	pos := 0
+	skipSpace := func() {
+		for pos < len(body) && strings.IndexByte(" \t\r\n", body[pos]) >= 0 {
+			pos++
+		}
+	}
+	for {
+		skipSpace()
+		if pos == len(body) {
+			return matchers, true
+		}
+		m := selectorMatcher{start: pos}
+		for pos < len(body) && (isLabelChar(body[pos]) || (body[pos] == '.' && pos > m.start)) {
+			pos++
+		}
+		name := body[m.start:pos]
+		skipSpace()
+		op := ""
+		for _, candidate := range []string{"=~", "!~", "!=", "="} {
+			if strings.HasPrefix(body[pos:], candidate) {
+				op = candidate
+				break
+			}
+		}
+		if name == "" || op == "" {
+			return nil, false
+		}
+		pos += len(op)
+		skipSpace()
+		end := quotedStringEnd(body, pos)
+		if end < 0 {
+			return nil, false
+		}
+		value, err := strconv.Unquote(body[pos:end])
+		if err != nil {
+			return nil, false
+		}
+		pos, m.end = end, end
+		m.matcher = &labels.Matcher{Type: matchTypes[op], Name: name, Value: value}
+		matchers = append(matchers, m)
+
+		skipSpace()
+		if pos < len(body) {
+			if body[pos] != ',' {
+				return nil, false
+			}
+			pos++
+		}
+	}
+}
+
+func isLabelChar(c byte) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// quotedStringEnd returns the offset after the string literal starting at pos, or -1 if there
+// is none.
+func quotedStringEnd(s string, pos int) int {
+	if pos >= len(s) || (s[pos] != '"' && s[pos] != '\'' && s[pos] != '`') {
+		return -1
+	}
+	quote := s[pos]
+	for i := pos + 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+		case s[i] == quote:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// closingBrace returns the offset of the brace which closes the one at open, skipping string
+// literals, or -1 if it is not closed.
+func closingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'', '`':
+			end := quotedStringEnd(s, i)
+			if end < 0 {
+				return -1
+			}
+			i = end - 1
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// withMatcher returns the selector body with the matcher name=~"value" added, or replacing the
+// first matcher of that name, unless one of them already matches. The separator used between
+// the existing matchers is kept.
+func withMatcher(body, name, value string) (string, bool) {
+	matchers, ok := parseSelectorMatchers(body)
+	if !ok {
+		return body, false
+	}
+	var ms []*labels.Matcher
+	for _, m := range matchers {
+		ms = append(ms, m.matcher)
+	}
+	if checkForMatcher(ms, name, labels.MatchRegexp, value) == nil {
+		return body, false
+	}
+
+	matcher := fmt.Sprintf("%s=~%s", name, strconv.Quote(value))
+	for _, m := range matchers {
+		if m.matcher.Name == name {
+			return body[:m.start] + matcher + body[m.end:], true
+		}
+	}
+	if len(matchers) == 0 {
+		return matcher, true
+	}
+	sep := ","
+	if strings.Contains(body, ", ") {
+		sep = ", "
+	}
+	last := matchers[len(matchers)-1].end
+	return body[:last] + sep + matcher + body[last:], true
+}
+
+// isLokiTarget reports whether the target queries Loki. The type of the target's own datasource
+// is used if it has one, then the type of the datasource variable it references, and only then
+// the type of the templated datasource.
+func isLokiTarget(d Dashboard, t Target) bool {
+	ds, err := t.GetDataSource()
+	if err != nil {
+		return false
+	}
+	if ds.Type != "" && !strings.HasPrefix(ds.Type, "$") {
+		return ds.Type == Loki
+	}
+	if strings.HasPrefix(ds.UID, "$") {
+		name := strings.Trim(strings.TrimPrefix(ds.UID, "$"), "{}")
+		if v := getTemplate(d, name); v != nil && v.Type == "datasource" {
+			return v.Query == Loki
+		}
+	}
+	templateDS := getTemplateDatasource(d)
+	return templateDS != nil && templateDS.Query == Loki
+}
+
+// logQLStreamSelectors locates the stream selectors of a LogQL query, including their braces.
+// Braces in string literals and in ${variable} references are skipped.
+func logQLStreamSelectors(expr string) []QueryRange {
+	var selectors []QueryRange
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '"', '\'', '`':
+			end := quotedStringEnd(expr, i)
+			if end < 0 {
+				return selectors
+			}
+			i = end - 1
+		case '{':
+			end := closingBrace(expr, i)
+			if end < 0 {
+				return selectors
+			}
+			if i == 0 || expr[i-1] != '$' {
+				selectors = append(selectors, QueryRange{Start: i, End: end + 1})
+			}
+			i = end
+		}
+	}
+	return selectors
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	dashv2 "github.com/grafana/grafana/apps/dashboard/pkg/apis/dashboard/v2"
@@ -14,59 +15,546 @@ import (
 // is structurally different from the classic dashboard and is handled by a
 // dedicated adapter.
 func isV2APIVersion(apiVersion string) bool {
-	v := apiVersion
-	if i := strings.LastIndex(v, "/"); i >= 0 {
-		v = v[i+1:]
-	}
-	return strings.HasPrefix(v, "v2")
+	return strings.HasPrefix(apiVersionOf(apiVersion), "v2")
+}
+
+// apiVersionOf returns the version of an apiVersion, without its group.
+func apiVersionOf(apiVersion string) string {
+	return apiVersion[strings.LastIndex(apiVersion, "/")+1:]
 }
 
 // newDashboardFromV2 converts a v2 dashboard spec into the linter's internal
 // Dashboard model so that all existing rules can run against it unchanged.
-func newDashboardFromV2(spec json.RawMessage, apiVersion string) (Dashboard, error) {
+// The uid of a v2 dashboard is the name in its resource metadata.
+func newDashboardFromV2(spec json.RawMessage, apiVersion string, metadata json.RawMessage) (Dashboard, error) {
 	var s dashv2.DashboardSpec
 	if err := json.Unmarshal(spec, &s); err != nil {
 		return Dashboard{}, fmt.Errorf("parsing v2 dashboard spec: %w", err)
 	}
 
-	panels, err := panelsFromV2(s.Elements)
+	src := &v2Source{}
+	panels, err := panelsFromV2(s.Elements, s.Layout, src)
 	if err != nil {
 		return Dashboard{}, err
 	}
 
 	d := Dashboard{
-		Title:      s.Title,
-		APIVersion: apiVersion,
-		Panels:     panels,
+		Title:        s.Title,
+		APIVersion:   apiVersion,
+		Panels:       panels,
+		Tags:         s.Tags,
+		Time:         &TimeRange{From: s.TimeSettings.From, To: s.TimeSettings.To},
+		Timezone:     deref(s.TimeSettings.Timezone),
+		GraphTooltip: graphTooltipFromV2(s.CursorSync),
+		Links:        dashboardLinksFromV2(s.Links),
+		v2:           src,
 	}
 	if s.Editable != nil {
 		d.Editable = *s.Editable
 	}
-	d.Templating.List = templatesFromV2(s.Variables)
+	if s.TimeSettings.AutoRefresh != "" {
+		d.Refresh = s.TimeSettings.AutoRefresh
+	}
+	if metadata != nil {
+		var m struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(metadata, &m); err != nil {
+			return Dashboard{}, fmt.Errorf("parsing v2 dashboard metadata: %w", err)
+		}
+		d.UID = m.Name
+	}
+	d.Templating.List = templatesFromV2(s.Variables, src)
 	d.Annotations.List = annotationsFromV2(s.Annotations)
 	return d, nil
 }
 
-// panelsFromV2 converts the v2 element map into the linter's panel slice.
-// Library panels are skipped because they carry no inline spec to lint.
-// Panels are sorted by id so output is deterministic (the element map has no
-// inherent order; layout/order is irrelevant to linting).
-func panelsFromV2(elements map[string]dashv2.DashboardElement) ([]Panel, error) {
-	var panels []Panel
-	for _, el := range elements {
-		if el.PanelKind == nil {
+// graphTooltipFromV2 maps the v2 cursor sync to the classic graphTooltip: 0 for none, 1 for a
+// shared crosshair and 2 for a shared tooltip.
+func graphTooltipFromV2(c dashv2.DashboardDashboardCursorSync) int {
+	switch c {
+	case dashv2.DashboardDashboardCursorSyncCrosshair:
+		return 1
+	case dashv2.DashboardDashboardCursorSyncTooltip:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func dashboardLinksFromV2(links []dashv2.DashboardDashboardLink) []DashboardLink {
+	var out []DashboardLink
+	for _, l := range links {
+		out = append(out, DashboardLink{
+			Title:       l.Title,
+			Type:        l.Type,
+			URL:         deref(l.Url),
+			Tags:        l.Tags,
+			AsDropdown:  l.AsDropdown,
+			IncludeVars: l.IncludeVars,
+			KeepTime:    l.KeepTime,
+			TargetBlank: l.TargetBlank,
+		})
+	}
+	return out
+}
+
+// v2Source maps the nodes of a dashboard built from a v2 spec back to where they came from, so
+// fixes made to the model can be written to the v2 document. Targets are the queries of their
+// panel, with the same index.
+type v2Source struct {
+	// elements are the element keys of the panels, by JSON pointer.
+	elements map[string]string
+	// containers are the paths in the spec of the rows and tabs, by JSON pointer.
+	containers map[string][]string
+	// variables are where the templates are in the v2 variables.
+	variables []v2Variable
+	// unplaced are the keys of the elements which are not placed in the layout, and missing
+	// the keys of the elements the layout references, but which are not defined.
+	unplaced, missing []string
+}
+
+// v2Variable is where a template is in the v2 variables.
+type v2Variable struct {
+	index int
+	// adhoc is set for group-by and ad hoc filter variables, which have their datasource next to
+	// their spec rather than in a query.
+	adhoc bool
+}
+
+// translate turns operations on the model into operations on the v2 document, including its
+// resource envelope. Only the properties the adapter reads from the v2 spec can be translated,
+// and those it derives are skipped. The other operations are dropped, and their paths returned.
+func (s *v2Source) translate(ops []PatchOperation) ([]PatchOperation, []string, error) {
+	// Added variables shift the indices of the variables after them.
+	src := v2Source{elements: s.elements, containers: s.containers, variables: append([]v2Variable(nil), s.variables...)}
+	translated := make([]PatchOperation, 0, len(ops))
+	var unsupported []string
+	for _, op := range ops {
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		path, value, ok := src.translatePath(op.Op, tokens, op.Value)
+		if !ok {
+			unsupported = append(unsupported, op.Path)
+			continue
+		}
+		if path == nil {
 			continue
 		}
-		p, err := panelFromV2(el.PanelKind.Spec)
+		op.Path, op.Value = formatPointer(append([]string{"spec"}, path...)), value
+		translated = append(translated, op)
+	}
+	return translated, unsupported, nil
+}
+
+// dropUnsupportedFixes removes the fixes which change the dashboard in ways that cannot be
+// written to its v2 document, and says so in the message of their results, so they are reported
+// rather than fixed.
+func (rs *ResultSet) dropUnsupportedFixes(d Dashboard) {
+	before, err := d.Marshal()
+	if err != nil {
+		return
+	}
+	var a map[string]interface{}
+	if err := json.Unmarshal(before, &a); err != nil {
+		return
+	}
+	for _, rc := range rs.results {
+		for i, r := range rc.Result.Results {
+			if r.Fix == nil || r.Severity == Fixed {
+				continue
+			}
+			// The fix is tried on a copy of the dashboard, as it changes what it is given.
+			var fixed Dashboard
+			if err := json.Unmarshal(before, &fixed); err != nil {
+				return
+			}
+			r.Fix(&fixed)
+			after, err := fixed.Marshal()
+			if err != nil {
+				return
+			}
+			var b map[string]interface{}
+			if err := json.Unmarshal(after, &b); err != nil {
+				return
+			}
+			if _, unsupported, err := d.v2.translate(diffJSON(nil, a, b)); err == nil && len(unsupported) == 0 {
+				continue
+			}
+			rc.Result.Results[i].Fix = nil
+			rc.Result.Results[i].Message += " (fix not supported for v2 dashboards)"
+		}
+	}
+}
+
+func (s *v2Source) translatePath(op string, tokens []string, value interface{}) ([]string, interface{}, bool) {
+	index := func(t string, n int) (int, bool) {
+		i, err := arrayIndex(t, n, false)
+		return i, err == nil
+	}
+
+	switch {
+	case len(tokens) == 1 && (tokens[0] == "title" || tokens[0] == "editable"):
+		return tokens, value, true
+
+	case len(tokens) == 2 && tokens[0] == "templating" && tokens[1] == "list" && op == "replace" && len(s.variables) == 0:
+		// Variables added to a dashboard without any replace the whole list.
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, nil, false
+		}
+		vars := make([]interface{}, len(list))
+		for i, t := range list {
+			if vars[i], ok = variableToV2(t); !ok {
+				return nil, nil, false
+			}
+			s.variables = append(s.variables, v2Variable{index: i})
+		}
+		return []string{"variables"}, vars, true
+
+	case len(tokens) == 3 && tokens[0] == "templating" && tokens[1] == "list" && op == "add":
+		i, err := arrayIndex(tokens[2], len(s.variables), true)
+		if err != nil {
+			return nil, nil, false
+		}
+		v, ok := variableToV2(value)
+		if !ok {
+			return nil, nil, false
+		}
+		pos := 0
+		if i < len(s.variables) {
+			pos = s.variables[i].index
+		} else if i > 0 {
+			pos = s.variables[i-1].index + 1
+		}
+		s.variables = append(s.variables[:i], append([]v2Variable{{index: pos}}, s.variables[i:]...)...)
+		for j := i + 1; j < len(s.variables); j++ {
+			s.variables[j].index++
+		}
+		return []string{"variables", strconv.Itoa(pos)}, v, true
+
+	case len(tokens) >= 4 && tokens[0] == "templating" && tokens[1] == "list":
+		i, ok := index(tokens[2], len(s.variables))
+		if !ok {
+			return nil, nil, false
+		}
+		v := s.variables[i]
+		path := []string{"variables", strconv.Itoa(v.index), "spec"}
+		switch rest := strings.Join(tokens[3:], "/"); rest {
+		case "name", "label", "multi", "allValue":
+			return append(path, tokens[3]), value, true
+		case "refresh":
+			refresh, ok := value.(float64)
+			return append(path, "refresh"), refreshToV2(int(refresh)), ok
+		default:
+			if v.adhoc {
+				return dataQueryDatasourceToV2([]string{"variables", strconv.Itoa(v.index)}, rest, value)
+			}
+			return dataQueryDatasourceToV2(append(path, "query"), rest, value)
+		}
+
+	case len(tokens) >= 3 && tokens[0] == "panels":
+		key, container, tokens := s.panelAt(tokens)
+		switch {
+		case container != nil:
+			if len(tokens) == 1 && tokens[0] == "title" {
+				return append(container, "title"), value, true
+			}
+			return nil, nil, false
+		case key == "" || len(tokens) == 0:
+			return nil, nil, false
+		}
+		path := []string{"elements", key, "spec"}
+		if len(tokens) == 1 && (tokens[0] == "title" || tokens[0] == "description") {
+			return append(path, tokens[0]), value, true
+		}
+		if tokens[0] == "datasource" {
+			// The panel datasource is taken from the first query, which is fixed along with it.
+			return nil, nil, true
+		}
+		if len(tokens) < 3 || tokens[0] != "targets" {
+			return nil, nil, false
+		}
+		// The number of queries is checked when the operation is applied.
+		if _, err := strconv.Atoi(tokens[1]); err != nil {
+			return nil, nil, false
+		}
+		path = append(path, "data", "spec", "queries", tokens[1], "spec")
+		switch rest := strings.Join(tokens[2:], "/"); rest {
+		case "expr":
+			return append(path, "query", "spec", "expr"), value, true
+		case "refId":
+			return append(path, "refId"), value, true
+		case "hide":
+			return append(path, "hidden"), value, true
+		default:
+			return dataQueryDatasourceToV2(append(path, "query"), rest, value)
+		}
+	}
+	return nil, nil, false
+}
+
+// panelAt finds the panel a path in the model starts with. It returns the key of its element, or
+// the path in the spec if it is a row or tab, and the rest of the path.
+func (s *v2Source) panelAt(tokens []string) (string, []string, []string) {
+	pointer := ""
+	for len(tokens) >= 2 && tokens[0] == "panels" {
+		pointer += "/panels/" + tokens[1]
+		tokens = tokens[2:]
+		if key, ok := s.elements[pointer]; ok {
+			return key, nil, tokens
+		}
+		if container, ok := s.containers[pointer]; ok && (len(tokens) == 0 || tokens[0] != "panels") {
+			return "", append([]string(nil), container...), tokens
+		}
+	}
+	return "", nil, nil
+}
+
+// dataQueryDatasourceToV2 translates the path of a datasource in the model, relative to a panel
+// query or variable, to its place in the v2 DataQuery, or group by or ad hoc variable, at path.
+func dataQueryDatasourceToV2(path []string, rest string, value interface{}) ([]string, interface{}, bool) {
+	switch rest {
+	case "datasource/uid":
+		return append(path, "datasource", "name"), value, true
+	case "datasource/type":
+		return append(path, "group"), value, true
+	case "datasource":
+		ds, err := GetDataSource(value)
+		if err != nil {
+			return nil, nil, false
+		}
+		return append(path, "datasource"), map[string]interface{}{"name": ds.UID}, true
+	}
+	return nil, nil, false
+}
+
+// variableToV2 converts a variable added to the model into a v2 variable. Only the datasource
+// and query variables the linter adds are supported.
+func variableToV2(value interface{}) (interface{}, bool) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	str := func(key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	refresh, _ := m["refresh"].(float64)
+	spec := map[string]interface{}{
+		"name":       str("name"),
+		"label":      str("label"),
+		"refresh":    string(refreshToV2(int(refresh))),
+		"regex":      "",
+		"current":    map[string]interface{}{"text": "", "value": ""},
+		"options":    []interface{}{},
+		"multi":      m["multi"] == true,
+		"includeAll": m["includeAll"] == true,
+	}
+	if allValue := str("allValue"); allValue != "" {
+		spec["allValue"] = allValue
+	}
+
+	switch str("type") {
+	case "datasource":
+		spec["pluginId"] = str("query")
+		return map[string]interface{}{"kind": "DatasourceVariable", "spec": spec}, true
+	case targetTypeQuery:
+		ds, err := GetDataSource(m["datasource"])
+		if err != nil {
+			return nil, false
+		}
+		spec["hide"] = "dontHide"
+		spec["skipUrlSync"] = false
+		spec["sort"] = "disabled"
+		spec["allowCustomValue"] = true
+		spec["query"] = map[string]interface{}{
+			"kind":       "DataQuery",
+			"group":      ds.Type,
+			"version":    "v0",
+			"datasource": map[string]interface{}{"name": ds.UID},
+			"spec":       map[string]interface{}{"query": str("query")},
+		}
+		return map[string]interface{}{"kind": "QueryVariable", "spec": spec}, true
+	}
+	return nil, false
+}
+
+// panelsFromV2 converts the v2 elements into the linter's panel hierarchy by walking the layout.
+// Rows and tabs become panels of type "row" and "tab", which hold the panels laid out in them,
+// and the panels keep the title of the rows and tabs they are in, for the messages of their
+// results. The position and repeat of the panels are taken from the layout items.
+//
+// Elements which are not placed in the layout are appended, sorted by id so output is
+// deterministic (the element map has no inherent order), so they are linted as well. They, and
+// references to missing elements, are recorded for the layout rule.
+// Library panels carry no inline spec, so they become references, which are
+// linted once resolved, see Dashboard.ResolveLibraryPanels.
+func panelsFromV2(elements map[string]dashv2.DashboardElement, layout dashv2.DashboardGridLayoutKindOrRowsLayoutKindOrAutoGridLayoutKindOrTabsLayoutKind, src *v2Source) ([]Panel, error) {
+	src.elements = map[string]string{}
+	src.containers = map[string][]string{}
+	w := v2LayoutWalker{elements: elements, src: src, placed: map[string]bool{}}
+	panels, err := w.layout(layout, "/panels", []string{"layout"}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	type element struct {
+		key   string
+		panel Panel
+	}
+	var unplaced []element
+	for key := range elements {
+		if w.placed[key] {
+			continue
+		}
+		p, ok, err := w.element(key, "")
 		if err != nil {
 			return nil, err
 		}
+		if ok {
+			unplaced = append(unplaced, element{key: key, panel: p})
+		}
+	}
+	sort.Slice(unplaced, func(i, j int) bool {
+		if unplaced[i].panel.Id != unplaced[j].panel.Id {
+			return unplaced[i].panel.Id < unplaced[j].panel.Id
+		}
+		return unplaced[i].key < unplaced[j].key
+	})
+	for _, el := range unplaced {
+		src.elements[fmt.Sprintf("/panels/%d", len(panels))] = el.key
+		src.unplaced = append(src.unplaced, el.key)
+		panels = append(panels, el.panel)
+	}
+	return panels, nil
+}
+
+// v2LayoutWalker builds the panels of the elements placed in a v2 layout.
+type v2LayoutWalker struct {
+	elements map[string]dashv2.DashboardElement
+	src      *v2Source
+	// placed are the keys of the elements placed in the layout.
+	placed map[string]bool
+}
+
+// layout returns the panels of the layout, which is at path in the v2 spec. pointer is the JSON
+// pointer of the panel list in the model, and section names the rows and tabs the layout is in.
+func (w *v2LayoutWalker) layout(l dashv2.DashboardGridLayoutKindOrRowsLayoutKindOrAutoGridLayoutKindOrTabsLayoutKind, pointer string, path []string, section string) ([]Panel, error) {
+	var panels []Panel
+	place := func(name string) (Panel, bool, error) {
+		p, ok, err := w.element(name, section)
+		if ok {
+			w.src.elements[fmt.Sprintf("%s/%d", pointer, len(panels))] = name
+		}
+		return p, ok, err
+	}
+	container := func(kind string, i int, title *string, repeat string, layout dashv2.DashboardGridLayoutKindOrRowsLayoutKindOrAutoGridLayoutKindOrTabsLayoutKind) error {
+		p := Panel{Type: kind, Title: deref(title), Repeat: repeat, section: section}
+		name := fmt.Sprintf("%s '%s'", kind, p.Title)
+		if p.Title == "" {
+			name = fmt.Sprintf("%s %d", kind, i+1)
+		}
+		if section != "" {
+			name = section + ", " + name
+		}
+		at := fmt.Sprintf("%s/%d", pointer, len(panels))
+		spec := append(append([]string(nil), path...), "spec", kind+"s", strconv.Itoa(i), "spec")
+		w.src.containers[at] = spec
+		var err error
+		p.Panels, err = w.layout(layout, at+"/panels", append(spec, "layout"), name)
 		panels = append(panels, p)
+		return err
+	}
+
+	switch {
+	case l.GridLayoutKind != nil:
+		for _, item := range l.GridLayoutKind.Spec.Items {
+			p, ok, err := place(item.Spec.Element.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			p.GridPos = &GridPos{H: int(item.Spec.Height), W: int(item.Spec.Width), X: int(item.Spec.X), Y: int(item.Spec.Y)}
+			if item.Spec.Repeat != nil {
+				p.Repeat = item.Spec.Repeat.Value
+				p.RepeatDirection = deref(item.Spec.Repeat.Direction)
+			}
+			panels = append(panels, p)
+		}
+	case l.AutoGridLayoutKind != nil:
+		for _, item := range l.AutoGridLayoutKind.Spec.Items {
+			p, ok, err := place(item.Spec.Element.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if item.Spec.Repeat != nil {
+				p.Repeat = item.Spec.Repeat.Value
+			}
+			panels = append(panels, p)
+		}
+	case l.RowsLayoutKind != nil:
+		for i, row := range l.RowsLayoutKind.Spec.Rows {
+			var repeat string
+			if row.Spec.Repeat != nil {
+				repeat = row.Spec.Repeat.Value
+			}
+			if err := container("row", i, row.Spec.Title, repeat, row.Spec.Layout); err != nil {
+				return nil, err
+			}
+		}
+	case l.TabsLayoutKind != nil:
+		for i, tab := range l.TabsLayoutKind.Spec.Tabs {
+			var repeat string
+			if tab.Spec.Repeat != nil {
+				repeat = tab.Spec.Repeat.Value
+			}
+			if err := container("tab", i, tab.Spec.Title, repeat, tab.Spec.Layout); err != nil {
+				return nil, err
+			}
+		}
 	}
-	sort.Slice(panels, func(i, j int) bool { return panels[i].Id < panels[j].Id })
 	return panels, nil
 }
 
+// element returns the panel of the element with the key, in the section. It is false if the
+// element does not exist, which is recorded as a missing reference.
+func (w *v2LayoutWalker) element(key, section string) (Panel, bool, error) {
+	el, ok := w.elements[key]
+	if !ok {
+		w.src.missing = append(w.src.missing, key)
+		return Panel{}, false, nil
+	}
+	w.placed[key] = true
+
+	var p Panel
+	switch {
+	case el.PanelKind != nil:
+		var err error
+		if p, err = panelFromV2(el.PanelKind.Spec); err != nil {
+			return Panel{}, false, err
+		}
+	case el.LibraryPanelKind != nil:
+		ls := el.LibraryPanelKind.Spec
+		p = Panel{
+			Id:           int(ls.Id),
+			Title:        ls.Title,
+			LibraryPanel: &LibraryPanelRef{UID: ls.LibraryPanel.Uid, Name: ls.LibraryPanel.Name},
+		}
+	default:
+		return Panel{}, false, nil
+	}
+	p.section = section
+	return p, true, nil
+}
+
 func panelFromV2(ps dashv2.DashboardPanelSpec) (Panel, error) {
 	p := Panel{
 		Id:          int(ps.Id),
@@ -76,6 +564,26 @@ func panelFromV2(ps dashv2.DashboardPanelSpec) (Panel, error) {
 		// "stat", "table", "text", ...).
 		Type:    ps.VizConfig.Group,
 		Targets: targetsFromV2(ps.Data.Spec.Queries),
+		Links:   panelLinksFromV2(ps.Links),
+	}
+
+	qo := ps.Data.Spec.QueryOptions
+	p.Interval = deref(qo.Interval)
+	p.TimeFrom = deref(qo.TimeFrom)
+	p.TimeShift = deref(qo.TimeShift)
+	if qo.MaxDataPoints != nil {
+		p.MaxDataPoints = int(*qo.MaxDataPoints)
+	}
+	for _, t := range ps.Data.Spec.Transformations {
+		opts, err := json.Marshal(t.Spec.Options)
+		if err != nil {
+			return Panel{}, fmt.Errorf("panel %q transformation %q: %w", ps.Title, t.Spec.Id, err)
+		}
+		p.Transformations = append(p.Transformations, Transformation{
+			Id:       t.Spec.Id,
+			Disabled: t.Spec.Disabled != nil && *t.Spec.Disabled,
+			Options:  opts,
+		})
 	}
 
 	// The v2 fieldConfig and panel options mirror the classic JSON shape, so a
@@ -108,6 +616,18 @@ func panelFromV2(ps dashv2.DashboardPanelSpec) (Panel, error) {
 	return p, nil
 }
 
+func panelLinksFromV2(links []dashv2.DashboardDataLink) []PanelLink {
+	var out []PanelLink
+	for _, l := range links {
+		out = append(out, PanelLink{
+			Title:       l.Title,
+			URL:         l.Url,
+			TargetBlank: l.TargetBlank != nil && *l.TargetBlank,
+		})
+	}
+	return out
+}
+
 func targetsFromV2(queries []dashv2.DashboardPanelQueryKind) []Target {
 	var targets []Target
 	for _, q := range queries {
@@ -116,6 +636,10 @@ func targetsFromV2(queries []dashv2.DashboardPanelQueryKind) []Target {
 			Hide:       q.Spec.Hidden,
 			Expr:       stringFromQuerySpec(q.Spec.Query, "expr"),
 			Datasource: datasourceFromV2(q.Spec.Query),
+			// The query options are in the datasource specific spec, as in classic targets.
+			LegendFormat: stringFromQuerySpec(q.Spec.Query, "legendFormat"),
+			Interval:     stringFromQuerySpec(q.Spec.Query, "interval"),
+			Format:       stringFromQuerySpec(q.Spec.Query, "format"),
 		})
 	}
 	return targets
@@ -126,21 +650,34 @@ func targetsFromV2(queries []dashv2.DashboardPanelQueryKind) []Target {
 // "$datasource") and "type" (the query group, e.g. "prometheus"/"loki").
 // Returns nil when there is no datasource reference.
 func datasourceFromV2(q dashv2.DashboardDataQueryKind) interface{} {
-	if q.Datasource == nil || q.Datasource.Name == nil || *q.Datasource.Name == "" {
+	if q.Datasource == nil {
+		return nil
+	}
+	return datasourceRefFromV2(q.Datasource.Name, q.Group)
+}
+
+// datasourceRefFromV2 converts the name and group of a v2 datasource reference to a model
+// datasource.
+func datasourceRefFromV2(name *string, group string) interface{} {
+	if name == nil || *name == "" {
 		return nil
 	}
-	m := map[string]interface{}{"uid": *q.Datasource.Name}
-	if q.Group != "" {
-		m["type"] = q.Group
+	m := map[string]interface{}{"uid": *name}
+	if group != "" {
+		m["type"] = group
 	}
 	return m
 }
 
-func templatesFromV2(vars []dashv2.DashboardVariableKind) []Template {
+func templatesFromV2(vars []dashv2.DashboardVariableKind, src *v2Source) []Template {
 	var templates []Template
-	for _, v := range vars {
+	for i, v := range vars {
 		if t, ok := templateFromV2(v); ok {
+			// The raw query is set like for classic dashboards, so the model survives JSON.
+			t.RawQuery = t.Query
 			templates = append(templates, t)
+			adhoc := v.GroupByVariableKind != nil || v.AdhocVariableKind != nil
+			src.variables = append(src.variables, v2Variable{index: i, adhoc: adhoc})
 		}
 	}
 	return templates
@@ -155,6 +692,7 @@ func templateFromV2(v dashv2.DashboardVariableKind) (Template, bool) {
 			Name:       s.Name,
 			Label:      deref(s.Label),
 			Multi:      s.Multi,
+			IncludeAll: s.IncludeAll,
 			AllValue:   deref(s.AllValue),
 			Refresh:    refreshFromV2(s.Refresh),
 			Query:      stringFromQuerySpec(s.Query, "query"),
@@ -163,18 +701,19 @@ func templateFromV2(v dashv2.DashboardVariableKind) (Template, bool) {
 	case v.DatasourceVariableKind != nil:
 		s := v.DatasourceVariableKind.Spec
 		return Template{
-			Type:     "datasource",
-			Name:     s.Name,
-			Label:    deref(s.Label),
-			Multi:    s.Multi,
-			AllValue: deref(s.AllValue),
-			Refresh:  refreshFromV2(s.Refresh),
+			Type:       "datasource",
+			Name:       s.Name,
+			Label:      deref(s.Label),
+			Multi:      s.Multi,
+			IncludeAll: s.IncludeAll,
+			AllValue:   deref(s.AllValue),
+			Refresh:    refreshFromV2(s.Refresh),
 			// The datasource type drives prometheus/loki detection in the rules.
 			Query: s.PluginId,
 		}, true
 	case v.CustomVariableKind != nil:
 		s := v.CustomVariableKind.Spec
-		return Template{Type: "custom", Name: s.Name, Label: deref(s.Label), Multi: s.Multi, AllValue: deref(s.AllValue), Query: s.Query}, true
+		return Template{Type: "custom", Name: s.Name, Label: deref(s.Label), Multi: s.Multi, IncludeAll: s.IncludeAll, AllValue: deref(s.AllValue), Query: s.Query}, true
 	case v.IntervalVariableKind != nil:
 		s := v.IntervalVariableKind.Spec
 		return Template{Type: "interval", Name: s.Name, Label: deref(s.Label), Query: s.Query}, true
@@ -185,18 +724,75 @@ func templateFromV2(v dashv2.DashboardVariableKind) (Template, bool) {
 		s := v.TextVariableKind.Spec
 		return Template{Type: "textbox", Name: s.Name, Label: deref(s.Label), Query: s.Query}, true
 	case v.AdhocVariableKind != nil:
-		s := v.AdhocVariableKind.Spec
-		return Template{Type: "adhoc", Name: s.Name, Label: deref(s.Label)}, true
+		k := v.AdhocVariableKind
+		t := Template{Type: "adhoc", Name: k.Spec.Name, Label: deref(k.Spec.Label)}
+		if k.Datasource != nil {
+			t.Datasource = datasourceRefFromV2(k.Datasource.Name, k.Group)
+		}
+		t.Filters = adHocFiltersFromV2(k.Spec.Filters)
+		t.BaseFilters = adHocFiltersFromV2(k.Spec.BaseFilters)
+		return t, true
 	case v.GroupByVariableKind != nil:
-		s := v.GroupByVariableKind.Spec
-		return Template{Type: "groupby", Name: s.Name, Label: deref(s.Label), Multi: s.Multi}, true
+		k := v.GroupByVariableKind
+		t := Template{
+			Type:    "groupby",
+			Name:    k.Spec.Name,
+			Label:   deref(k.Spec.Label),
+			Multi:   k.Spec.Multi,
+			Current: variableOptionFromV2(k.Spec.Current),
+		}
+		if k.Datasource != nil {
+			t.Datasource = datasourceRefFromV2(k.Datasource.Name, k.Group)
+		}
+		if k.Spec.DefaultValue != nil {
+			t.DefaultValue = variableOptionFromV2(*k.Spec.DefaultValue)
+		}
+		for _, o := range k.Spec.Options {
+			t.Options = append(t.Options, variableOptionFromV2(o))
+		}
+		return t, true
 	case v.SwitchVariableKind != nil:
 		s := v.SwitchVariableKind.Spec
-		return Template{Type: "switch", Name: s.Name, Label: deref(s.Label)}, true
+		return Template{
+			Type:    "switch",
+			Name:    s.Name,
+			Label:   deref(s.Label),
+			Current: RawTemplateValue{"text": s.Current, "value": s.Current},
+			Options: []RawTemplateValue{
+				{"text": s.EnabledValue, "value": s.EnabledValue},
+				{"text": s.DisabledValue, "value": s.DisabledValue},
+			},
+		}, true
 	}
 	return Template{}, false
 }
 
+// variableOptionFromV2 converts a v2 variable option to a model template value. The text and
+// value are either a string or an array of strings, which are converted through their JSON.
+func variableOptionFromV2(o dashv2.DashboardVariableOption) RawTemplateValue {
+	buf, err := json.Marshal(o)
+	if err != nil {
+		return nil
+	}
+	var v RawTemplateValue
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return nil
+	}
+	delete(v, "selected")
+	if v["text"] == nil && v["value"] == nil {
+		return nil
+	}
+	return v
+}
+
+func adHocFiltersFromV2(filters []dashv2.DashboardAdHocFilterWithLabels) []AdHocFilter {
+	var out []AdHocFilter
+	for _, f := range filters {
+		out = append(out, AdHocFilter{Key: f.Key, Operator: f.Operator, Value: f.Value})
+	}
+	return out
+}
+
 func annotationsFromV2(anns []dashv2.DashboardAnnotationQueryKind) []Annotation {
 	var out []Annotation
 	for _, a := range anns {
@@ -235,6 +831,18 @@ func refreshFromV2(r dashv2.DashboardVariableRefresh) int {
 	}
 }
 
+// refreshToV2 is the inverse of refreshFromV2.
+func refreshToV2(r int) dashv2.DashboardVariableRefresh {
+	switch r {
+	case 2:
+		return dashv2.DashboardVariableRefreshOnTimeRangeChanged
+	case 1:
+		return dashv2.DashboardVariableRefreshOnDashboardLoad
+	default:
+		return dashv2.DashboardVariableRefreshNever
+	}
+}
+
 func deref(s *string) string {
 	if s == nil {
 		return ""
@@ -10,13 +10,19 @@ import (
 
 // A minimal kubernetes "v2" dashboard exercising the parts the adapter maps:
 // a panel (with type, unit, a prometheus query and datasource), a datasource
-// variable, a query variable, and an annotation query.
+// variable, a query variable, an annotation query, and the dashboard metadata,
+// time settings, links and layout.
 const v2Dashboard = `{
 	"apiVersion": "dashboard.grafana.app/v2",
 	"kind": "Dashboard",
+	"metadata": { "name": "v2-test" },
 	"spec": {
 		"title": "V2 Test",
 		"editable": true,
+		"tags": [ "node" ],
+		"cursorSync": "Tooltip",
+		"timeSettings": { "from": "now-1h", "to": "now", "timezone": "utc", "autoRefresh": "30s" },
+		"links": [ { "title": "Docs", "type": "link", "url": "https://example.com", "targetBlank": true } ],
 		"variables": [
 			{
 				"kind": "DatasourceVariable",
@@ -48,23 +54,37 @@ const v2Dashboard = `{
 					"id": 1,
 					"title": "CPU",
 					"description": "cpu usage",
-					"data": { "kind": "QueryGroup", "spec": { "queries": [
+					"links": [ { "title": "Runbook", "url": "https://example.com/runbook" } ],
+					"data": { "kind": "QueryGroup", "spec": {
+						"queryOptions": { "interval": "1m", "maxDataPoints": 500, "timeFrom": "2h", "timeShift": "1d" },
+						"transformations": [ { "kind": "organize", "spec": { "id": "organize", "options": { "renameByName": {} } } } ],
+						"queries": [
 						{ "kind": "PanelQuery", "spec": {
 							"refId": "A", "hidden": false,
 							"query": {
 								"kind": "DataQuery", "group": "prometheus", "version": "v0",
 								"datasource": { "name": "$datasource" },
-								"spec": { "expr": "sum(rate(node_cpu_seconds_total{cluster=\"$cluster\"}[5m]))" }
+								"spec": { "expr": "sum(rate(node_cpu_seconds_total{cluster=\"$cluster\"}[5m]))", "legendFormat": "{{cpu}}", "format": "time_series" }
 							}
 						}}
 					] } },
 					"vizConfig": {
 						"kind": "VizConfig", "group": "timeseries", "version": "1.0",
-						"spec": { "options": {}, "fieldConfig": { "defaults": { "unit": "percent" }, "overrides": [] } }
+						"spec": { "options": {}, "fieldConfig": { "defaults": {
+							"unit": "percent",
+							"thresholds": { "mode": "absolute", "steps": [ { "color": "green", "value": null }, { "color": "red", "value": 80 } ] }
+						}, "overrides": [] } }
 					}
 				}
 			}
-		}
+		},
+		"layout": { "kind": "GridLayout", "spec": { "items": [
+			{ "kind": "GridLayoutItem", "spec": {
+				"x": 0, "y": 0, "width": 12, "height": 8,
+				"element": { "kind": "ElementReference", "name": "panel-1" },
+				"repeat": { "mode": "variable", "value": "cluster", "direction": "h" }
+			} }
+		] } }
 	}
 }`
 
@@ -130,6 +150,42 @@ func TestParseV2Dashboard(t *testing.T) {
 		require.Len(t, d.Annotations.List, 1)
 		assert.Equal(t, "Annotations & Alerts", d.Annotations.List[0].Name)
 	})
+
+	t.Run("dashboard", func(t *testing.T) {
+		assert.Equal(t, "v2-test", d.UID)
+		assert.Equal(t, []string{"node"}, d.Tags)
+		assert.Equal(t, "now-1h", d.Time.From)
+		assert.Equal(t, "now", d.Time.To)
+		assert.Equal(t, "utc", d.Timezone)
+		assert.Equal(t, "30s", d.GetRefresh())
+		assert.Equal(t, 2, d.GraphTooltip)
+		require.Len(t, d.Links, 1)
+		assert.Equal(t, DashboardLink{Title: "Docs", Type: "link", URL: "https://example.com", TargetBlank: true}, d.Links[0])
+	})
+
+	t.Run("panel options", func(t *testing.T) {
+		p := d.GetPanels()[0]
+		assert.Equal(t, &GridPos{H: 8, W: 12, X: 0, Y: 0}, p.GridPos)
+		assert.Equal(t, "cluster", p.Repeat)
+		assert.Equal(t, "h", p.RepeatDirection)
+		assert.Equal(t, "1m", p.Interval)
+		assert.Equal(t, 500, p.MaxDataPoints)
+		assert.Equal(t, "2h", p.TimeFrom)
+		assert.Equal(t, "1d", p.TimeShift)
+		require.Len(t, p.Transformations, 1)
+		assert.Equal(t, "organize", p.Transformations[0].Id)
+		assert.JSONEq(t, `{"renameByName": {}}`, string(p.Transformations[0].Options))
+		assert.Equal(t, []PanelLink{{Title: "Runbook", URL: "https://example.com/runbook"}}, p.Links)
+		require.NotNil(t, p.FieldConfig.Defaults.Thresholds)
+		assert.Equal(t, "absolute", p.FieldConfig.Defaults.Thresholds.Mode)
+		require.Len(t, p.FieldConfig.Defaults.Thresholds.Steps, 2)
+		assert.Nil(t, p.FieldConfig.Defaults.Thresholds.Steps[0].Value)
+		assert.Equal(t, 80.0, *p.FieldConfig.Defaults.Thresholds.Steps[1].Value)
+
+		tg := p.Targets[0]
+		assert.Equal(t, "{{cpu}}", tg.LegendFormat)
+		assert.Equal(t, "time_series", tg.Format)
+	})
 }
 
 // ruleHasError reports whether the named rule produced any Error-severity result.
@@ -185,3 +241,240 @@ func TestLintV2Dashboard(t *testing.T) {
 			"on-time-range rule should fire for an onDashboardLoad query variable")
 	})
 }
+
+func TestFixV2Dashboard(t *testing.T) {
+	buf := []byte(strings.Replace(v2Dashboard, `"refresh": "onTimeRangeChanged"`, `"refresh": "onDashboardLoad"`, 1))
+	d, err := NewDashboard(buf)
+	require.NoError(t, err)
+	before, err := d.Marshal()
+	require.NoError(t, err)
+
+	d.Editable = false
+	d.Templating.List[1].Refresh = 2
+	d.Panels[0].Targets[0].Expr = "sum(rate(node_cpu_seconds_total[$__rate_interval]))"
+	fixed, err := fixedJSON(before, d, buf)
+	require.NoError(t, err)
+
+	expected := strings.NewReplacer(
+		`"editable": true`, `"editable": false`,
+		`"spec": { "expr": "sum(rate(node_cpu_seconds_total{cluster=\"$cluster\"}[5m]))",`,
+		`"spec": { "expr": "sum(rate(node_cpu_seconds_total[$__rate_interval]))",`,
+	).Replace(v2Dashboard)
+	require.Equal(t, expected, string(fixed))
+
+	t.Run("datasource", func(t *testing.T) {
+		d, err := NewDashboard(buf)
+		require.NoError(t, err)
+		ds := map[string]interface{}{"uid": "${datasource}", "type": "prometheus"}
+		d.Panels[0].Datasource = ds
+		d.Panels[0].Targets[0].Datasource = ds
+		d.Templating.List[1].Datasource = map[string]interface{}{"uid": "$datasource", "type": "prometheus"}
+		fixed, err := fixedJSON(before, d, buf)
+		require.NoError(t, err)
+		require.Contains(t, string(fixed), `"datasource": { "name": "${datasource}" },
+								"spec": { "expr"`)
+		require.Contains(t, string(fixed), `"datasource": { "name": "$datasource" },
+						"spec": { "query": "label_values(up, cluster)" }`)
+	})
+
+	t.Run("variables", func(t *testing.T) {
+		d, err := NewDashboard(buf)
+		require.NoError(t, err)
+		fixMissingTemplate("job")(&d)
+		fixMissingTemplate("instance")(&d)
+		fixed, err := fixedJSON(before, d, buf)
+		require.NoError(t, err)
+
+		f, err := NewDashboard(fixed)
+		require.NoError(t, err)
+		require.Equal(t, []string{"datasource", "job", "instance", "cluster"}, templateNames(f.Templating.List))
+		job := f.Templating.List[1]
+		require.Equal(t, "query", job.Type)
+		require.Equal(t, `label_values(node_cpu_seconds_total, job)`, job.Query)
+		require.Equal(t, map[string]interface{}{"uid": "$datasource", "type": "prometheus"}, job.Datasource)
+		require.True(t, job.Multi)
+		require.Equal(t, ".+", job.AllValue)
+		require.Equal(t, 2, job.Refresh)
+		require.Equal(t, `label_values(node_cpu_seconds_total{job=~"$job"}, instance)`, f.Templating.List[2].Query)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		d, err := NewDashboard(buf)
+		require.NoError(t, err)
+		d.Panels[0].Type = "stat"
+		d.Panels[0].Title = "fixed"
+		// Changes which cannot be written to the v2 document are left out.
+		fixed, err := fixedJSON(before, d, buf)
+		require.NoError(t, err)
+		f, err := NewDashboard(fixed)
+		require.NoError(t, err)
+		require.Equal(t, "fixed", f.Panels[0].Title)
+		require.NotEqual(t, "stat", f.Panels[0].Type)
+	})
+
+	t.Run("unsupported fixes are reported", func(t *testing.T) {
+		d, err := NewDashboard(buf)
+		require.NoError(t, err)
+		rule := &DashboardRuleFunc{name: "rule"}
+		rs := ResultSet{}
+		rs.AddResult(ResultContext{Rule: rule, Dashboard: &d, Result: RuleResults{[]FixableResult{
+			{Result: Result{Severity: Error, Message: "type"}, Fix: func(d *Dashboard) { d.Panels[0].Type = "stat" }},
+			{Result: Result{Severity: Error, Message: "title"}, Fix: func(d *Dashboard) { d.Panels[0].Title = "fixed" }},
+		}}})
+		rs.dropUnsupportedFixes(d)
+
+		results := rs.results[0].Result.Results
+		require.Nil(t, results[0].Fix)
+		require.Equal(t, "type (fix not supported for v2 dashboards)", results[0].Message)
+		require.NotNil(t, results[1].Fix)
+		require.Equal(t, "title", results[1].Message)
+	})
+}
+
+func templateNames(templates []Template) []string {
+	var names []string
+	for _, t := range templates {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func TestParseV2Layout(t *testing.T) {
+	buf := []byte(v2LayoutDashboard)
+	d, err := NewDashboard(buf)
+	require.NoError(t, err)
+
+	// Rows and tabs hold the panels laid out in them, and the unplaced element comes last.
+	require.Len(t, d.Panels, 3)
+	overview, details, network := d.Panels[0], d.Panels[1], d.Panels[2]
+	require.Equal(t, "row", overview.Type)
+	require.Equal(t, "Overview", overview.Title)
+	require.Len(t, overview.Panels, 1)
+	cpu := overview.Panels[0]
+	require.Equal(t, "CPU", cpu.Title)
+	require.Equal(t, &GridPos{H: 8, W: 12}, cpu.GridPos)
+	require.Equal(t, "env", details.Repeat)
+	require.Len(t, details.Panels, 2)
+	require.Equal(t, "tab", details.Panels[0].Type)
+	require.Equal(t, "Memory", details.Panels[0].Title)
+	memory := details.Panels[0].Panels[0]
+	require.Equal(t, "Memory", memory.Title)
+	require.Equal(t, "instance", memory.Repeat)
+	require.Equal(t, "Disk", details.Panels[1].Panels[0].Title)
+	require.Equal(t, "Network", network.Title)
+
+	t.Run("messages", func(t *testing.T) {
+		for p, expected := range map[*Panel]string{
+			&cpu:     "Dashboard 'layout', row 'Overview', panel 'CPU' is broken",
+			&memory:  "Dashboard 'layout', row 'Details', tab 'Memory', panel 'Memory' is broken",
+			&network: "Dashboard 'layout', panel 'Network' is broken",
+		} {
+			require.Equal(t, expected, panelMessage(d, *p, "is broken"))
+		}
+		require.Equal(t, "Dashboard 'layout', row 'Details', tab 'Memory', panel 'Memory', target idx '0' is broken",
+			targetMessage(d, memory, Target{}, "is broken"))
+	})
+
+	t.Run("fixes", func(t *testing.T) {
+		before, err := d.Marshal()
+		require.NoError(t, err)
+		f := d
+		f.Panels = append([]Panel(nil), d.Panels...)
+		f.Panels[0].Title = "Summary"
+		f.Panels[1].Panels = append([]Panel(nil), details.Panels...)
+		f.Panels[1].Panels[0].Panels = []Panel{memory}
+		f.Panels[1].Panels[0].Panels[0].Description = "Memory usage"
+		fixed, err := fixedJSON(before, f, buf)
+		require.NoError(t, err)
+
+		expected := strings.NewReplacer(
+			`"title": "Overview"`, `"title": "Summary"`,
+			`"title": "Memory", "vizConfig": { "group": "timeseries" } }`,
+			`"title": "Memory", "vizConfig": { "group": "timeseries" }, "description": "Memory usage" }`,
+		).Replace(v2LayoutDashboard)
+		require.Equal(t, expected, string(fixed))
+	})
+}
+
+const v2FilterVariablesDashboard = `{
+	"apiVersion": "dashboard.grafana.app/v2",
+	"kind": "Dashboard",
+	"spec": {
+		"title": "filters",
+		"variables": [
+			{ "kind": "DatasourceVariable", "spec": { "name": "datasource", "pluginId": "prometheus" } },
+			{
+				"kind": "GroupByVariable",
+				"group": "prometheus",
+				"datasource": { "name": "prom-1" },
+				"spec": {
+					"name": "groupby",
+					"multi": true,
+					"current": { "text": ["job"], "value": ["job"] },
+					"defaultValue": { "text": "instance", "value": "instance" },
+					"options": [ { "text": "job", "value": "job" }, { "text": "instance", "value": "instance" } ]
+				}
+			},
+			{
+				"kind": "AdhocVariable",
+				"group": "prometheus",
+				"spec": {
+					"name": "filters",
+					"baseFilters": [ { "key": "cluster", "operator": "=", "value": "eu" } ],
+					"filters": [ { "key": "pod", "operator": "=~", "value": "api-.*" } ],
+					"defaultKeys": []
+				}
+			},
+			{ "kind": "SwitchVariable", "spec": { "name": "rate", "current": "true", "enabledValue": "true", "disabledValue": "false" } }
+		],
+		"elements": {},
+		"layout": { "kind": "GridLayout", "spec": { "items": [] } }
+	}
+}`
+
+func TestV2FilterVariables(t *testing.T) {
+	d, err := NewDashboard([]byte(v2FilterVariablesDashboard))
+	require.NoError(t, err)
+	require.Equal(t, []string{"datasource", "groupby", "filters", "rate"}, templateNames(d.Templating.List))
+
+	groupBy := d.Templating.List[1]
+	assert.Equal(t, map[string]interface{}{"uid": "prom-1", "type": "prometheus"}, groupBy.Datasource)
+	assert.Equal(t, RawTemplateValue{"text": []interface{}{"job"}, "value": []interface{}{"job"}}, groupBy.Current)
+	assert.Equal(t, RawTemplateValue{"text": "instance", "value": "instance"}, groupBy.DefaultValue)
+	assert.Len(t, groupBy.Options, 2)
+
+	filters := d.Templating.List[2]
+	assert.Nil(t, filters.Datasource)
+	assert.Equal(t, []AdHocFilter{{Key: "cluster", Operator: "=", Value: "eu"}}, filters.BaseFilters)
+	assert.Equal(t, []AdHocFilter{{Key: "pod", Operator: "=~", Value: "api-.*"}}, filters.Filters)
+
+	rate := d.Templating.List[3]
+	assert.Equal(t, RawTemplateValue{"text": "true", "value": "true"}, rate.Current)
+	assert.Equal(t, []RawTemplateValue{{"text": "true", "value": "true"}, {"text": "false", "value": "false"}}, rate.Options)
+
+	expanded, err := expandVariables(`sum by ($groupby) (up{$filters}) * $rate`, d.Templating.List)
+	require.NoError(t, err)
+	assert.Equal(t, `sum by (job) (up{cluster="eu",pod=~"api-.*"}) * true`, expanded)
+
+	t.Run("fix datasource", func(t *testing.T) {
+		config := NewConfigurationFile()
+		config.Autofix = true
+		linter, err := NewLinter(WithRules(NewTemplateGroupByAdHocDatasourceRule()), WithConfig(config))
+		require.NoError(t, err)
+		res, err := linter.Lint([]byte(v2FilterVariablesDashboard))
+		require.NoError(t, err)
+		require.Equal(t, Fixed, res.Results.MaximumSeverity())
+
+		// The datasource of the ad hoc variable, which has none, is added at the end of it.
+		expected := strings.NewReplacer(
+			`"datasource": { "name": "prom-1" }`, `"datasource": { "name": "$datasource" }`,
+			`"defaultKeys": []
+				}`, `"defaultKeys": []
+				},
+				"datasource": {
+					"name": "$datasource"
+				}`,
+		).Replace(v2FilterVariablesDashboard)
+		require.Equal(t, expected, string(res.Fixed))
+	})
+}
@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/prometheus/prometheus/promql/parser/posrange"
 )
 
 // https://grafana.com/docs/grafana/latest/variables/variable-types/global-variables/
@@ -146,6 +148,13 @@ func variableSampleValue(s string, variables []Template) (string, error) {
 		if v.Name != name {
 			continue
 		}
+		// Group by and ad hoc variables expand to label names and matchers, whatever the format
+		switch v.Type {
+		case "groupby":
+			return groupBySampleValue(v), nil
+		case "adhoc":
+			return adHocSampleValue(v), nil
+		}
 		// if it has a current value, use it
 		c, err := v.Current.Get()
 		if err != nil {
@@ -169,6 +178,66 @@ func variableSampleValue(s string, variables []Template) (string, error) {
 	return stringValue(name, name, kind, format)
 }
 
+// groupBySampleValue returns the labels a group by variable groups by, e.g. in `by ($groupby)`:
+// its current values, its options or else its name.
+func groupBySampleValue(v Template) string {
+	labels := rawTemplateValues(v.Current)
+	if len(labels) == 0 {
+		for _, o := range v.Options {
+			labels = append(labels, rawTemplateValues(o)...)
+		}
+	}
+	if len(labels) == 0 {
+		labels = []string{v.Name}
+	}
+	return strings.Join(labels, ",")
+}
+
+// rawTemplateValues returns the non empty values of a template value, which is either a string or
+// a list of strings.
+func rawTemplateValues(v RawTemplateValue) []string {
+	var values []interface{}
+	switch val := v["value"].(type) {
+	case string:
+		values = []interface{}{val}
+	case []interface{}:
+		values = val
+	}
+	var out []string
+	for _, e := range values {
+		if s, ok := e.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// adHocSampleValue returns the label matchers of the filters of an ad hoc variable, e.g. in
+// `up{$filters}`, or a matcher on its name if it has none, so that the selector is not empty.
+func adHocSampleValue(v Template) string {
+	var matchers []string
+	for _, f := range append(append([]AdHocFilter(nil), v.BaseFilters...), v.Filters...) {
+		if f.Key == "" {
+			continue
+		}
+		op := f.Operator
+		switch op {
+		case "=", "!=", "=~", "!~":
+		case "=|":
+			op = "=~"
+		case "!=|":
+			op = "!~"
+		default:
+			op = "="
+		}
+		matchers = append(matchers, f.Key+op+strconv.Quote(f.Value))
+	}
+	if len(matchers) == 0 {
+		matchers = []string{v.Name + "=" + strconv.Quote(v.Name)}
+	}
+	return strings.Join(matchers, ",")
+}
+
 var variableRegexp = regexp.MustCompile(
 	strings.Join([]string{
 		`\$([[:word:]]+)`,    // $var syntax
@@ -178,20 +247,72 @@ var variableRegexp = regexp.MustCompile(
 )
 
 func expandVariables(expr string, variables []Template) (string, error) {
-	parts := strings.Split(expr, "\"")
-	for i, part := range parts {
+	expanded, _, err := expandVariablesMapped(expr, variables)
+	return expanded, err
+}
+
+// expandedVariable records that the variable reference at [origStart, origEnd) of an expression
+// was replaced by the sample value at [start, end) of the expanded expression.
+type expandedVariable struct {
+	start, end         int
+	origStart, origEnd int
+}
+
+// offsetMap maps byte offsets in an expanded expression back to the original expression.
+type offsetMap []expandedVariable
+
+// original returns the offset in the original expression. An offset within a sample value maps
+// to the start of the variable reference, or to its end if end is true.
+func (m offsetMap) original(pos int, end bool) int {
+	delta := 0
+	for _, v := range m {
+		if pos <= v.start {
+			break
+		}
+		if pos >= v.end {
+			delta = v.origEnd - v.end
+			continue
+		}
+		if end {
+			return v.origEnd
+		}
+		return v.origStart
+	}
+	return pos + delta
+}
+
+// queryRange maps a position range in the expanded expression to the original expression.
+func (m offsetMap) queryRange(r posrange.PositionRange) QueryRange {
+	return QueryRange{
+		Start: m.original(int(r.Start), false),
+		End:   m.original(int(r.End), true),
+	}
+}
+
+// expandVariablesMapped expands variables like expandVariables, and also returns the map from
+// offsets in the expanded expression to offsets in the original one.
+func expandVariablesMapped(expr string, variables []Template) (string, offsetMap, error) {
+	var sb strings.Builder
+	var m offsetMap
+	// offset is where the current part starts in the original expression
+	offset := 0
+	for i, part := range strings.Split(expr, "\"") {
+		if i > 0 {
+			sb.WriteString("\"")
+			offset++
+		}
 		if i%2 == 1 {
 			// Inside a double quote string, just add it
+			sb.WriteString(part)
+			offset += len(part)
 			continue
 		}
 
-		// Accumulator to store the processed submatches
-		var subparts []string
 		// Cursor indicates where we are in the part being processed
 		cursor := 0
 		for _, v := range variableRegexp.FindAllStringSubmatchIndex(part, -1) {
 			// Add all until match starts
-			subparts = append(subparts, part[cursor:v[0]])
+			sb.WriteString(part[cursor:v[0]])
 			// Iterate on all the subgroups and find the one that matched
 			for j := 2; j < len(v); j += 2 {
 				if v[j] < 0 {
@@ -200,19 +321,20 @@ func expandVariables(expr string, variables []Template) (string, error) {
 				// Replace the match with sample value
 				val, err := variableSampleValue(part[v[j]:v[j+1]], variables)
 				if err != nil {
-					return "", err
+					return "", nil, err
 				}
-				subparts = append(subparts, val)
+				start := sb.Len()
+				sb.WriteString(val)
+				m = append(m, expandedVariable{start: start, end: sb.Len(), origStart: offset + v[0], origEnd: offset + v[1]})
 			}
 			// Move the start cursor at the end of the current match
 			cursor = v[1]
 		}
 		// Add rest of the string
-		subparts = append(subparts, parts[i][cursor:])
-		// Merge all back into the parts
-		parts[i] = strings.Join(subparts, "")
+		sb.WriteString(part[cursor:])
+		offset += len(part)
 	}
-	return strings.Join(parts, "\""), nil
+	return sb.String(), m, nil
 }
 
 func expandLogQLVariables(expr string, variables []Template) (string, error) {
@@ -2,6 +2,7 @@ package lint
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -195,9 +196,70 @@ func TestVariableExpansion(t *testing.T) {
 			},
 			result: "sum (rate(cpu{}[interval]))",
 		},
+		{
+			desc: "Should replace group by variables with the labels of their current value",
+			expr: "sum by (${groupby:csv}) (rate(cpu{}[$__rate_interval]))",
+			variables: []Template{
+				{Name: "groupby", Type: "groupby", Current: map[string]interface{}{"value": []interface{}{"job", "instance"}}},
+			},
+			result: "sum by (job,instance) (rate(cpu{}[8869990787ms]))",
+		},
+		{
+			desc: "Should replace group by variables with their options, or else their name",
+			expr: "sum by ($groupby) (cpu) / sum by ($other) (cpu)",
+			variables: []Template{
+				{Name: "groupby", Type: "groupby", Current: map[string]interface{}{"value": []interface{}{}}, Options: []RawTemplateValue{{"value": "job"}, {"value": "pod"}}},
+				{Name: "other", Type: "groupby"},
+			},
+			result: "sum by (job,pod) (cpu) / sum by (other) (cpu)",
+		},
+		{
+			desc: "Should replace ad hoc variables with label matchers",
+			expr: "up{job=\"node\", $filters} / up{$empty}",
+			variables: []Template{
+				{Name: "filters", Type: "adhoc",
+					BaseFilters: []AdHocFilter{{Key: "cluster", Operator: "=", Value: "eu"}},
+					Filters:     []AdHocFilter{{Key: "pod", Operator: "=~", Value: "api-.*"}, {Key: "env", Operator: "!=|", Value: "dev"}}},
+				{Name: "empty", Type: "adhoc"},
+			},
+			result: "up{job=\"node\", cluster=\"eu\",pod=~\"api-.*\",env!~\"dev\"} / up{empty=\"empty\"}",
+		},
+		{
+			desc: "Should replace switch variables with their current value",
+			expr: "up{enabled=\"true\"} == $switch",
+			variables: []Template{
+				{Name: "switch", Type: "switch", Current: map[string]interface{}{"value": "1"}, Options: []RawTemplateValue{{"value": "1"}, {"value": "0"}}},
+			},
+			result: "up{enabled=\"true\"} == 1",
+		},
 	} {
 		s, err := expandVariables(tc.expr, tc.variables)
 		require.Equal(t, tc.err, err)
 		require.Equal(t, tc.result, s, tc.desc)
 	}
 }
+
+func TestVariableExpansionOffsets(t *testing.T) {
+	expr := `sum(rate(foo{job=~"$job"}[$__rate_interval])) by ($group) / bar[5m]`
+	expanded, offsets, err := expandVariablesMapped(expr, nil)
+	require.NoError(t, err)
+	require.Equal(t, `sum(rate(foo{job=~"$job"}[8869990787ms])) by (group) / bar[5m]`, expanded)
+
+	for _, tc := range []struct {
+		expanded string
+		original string
+	}{
+		{expanded: "foo", original: "foo"},
+		{expanded: "[8869990787ms]", original: "[$__rate_interval]"},
+		{expanded: "8869990787", original: "$__rate_interval"},
+		{expanded: "(group)", original: "($group)"},
+		{expanded: "bar[5m]", original: "bar[5m]"},
+	} {
+		start := strings.Index(expanded, tc.expanded)
+		rng := QueryRange{
+			Start: offsets.original(start, false),
+			End:   offsets.original(start+len(tc.expanded), true),
+		}
+		require.Equal(t, tc.original, expr[rng.Start:rng.End], tc.expanded)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -8,8 +9,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
-	"github.com/zeitlinger/conflate"
 
 	"github.com/grafana/dashboard-linter/lint"
 )
@@ -17,8 +18,15 @@ import (
 var lintStrictFlag bool
 var lintVerboseFlag bool
 var lintAutofixFlag bool
+var lintDiffFlag bool
 var lintReadFromStdIn bool
 var lintConfigFlag string
+var lintHintsFlag bool
+var lintOutputFlag []string
+var lintGroupByFlag string
+var lintSummaryFlag bool
+var lintLibraryPanelsFlag string
+var lintLibraryPanelFlag bool
 
 // lintCmd represents the lint command
 var lintCmd = &cobra.Command{
@@ -36,10 +44,6 @@ var lintCmd = &cobra.Command{
 		var filename string
 
 		if lintReadFromStdIn {
-			if lintAutofixFlag {
-				return fmt.Errorf("can't read from stdin and autofix")
-			}
-
 			buf, err = io.ReadAll(os.Stdin)
 			if err != nil {
 				return fmt.Errorf("failed to read stdin: %v", err)
@@ -52,11 +56,6 @@ var lintCmd = &cobra.Command{
 			}
 		}
 
-		dashboard, err := lint.NewDashboard(buf)
-		if err != nil {
-			return fmt.Errorf("failed to parse dashboard: %v", err)
-		}
-
 		// if no config flag was passed, set a default path of a .lint file in the dashboards directory
 		if lintConfigFlag == "" {
 			lintConfigFlag = path.Join(path.Dir(filename), ".lint")
@@ -67,69 +66,188 @@ var lintCmd = &cobra.Command{
 			return fmt.Errorf("failed to load lint config: %v", err)
 		}
 		config.Verbose = lintVerboseFlag
-		config.Autofix = lintAutofixFlag
+		config.Autofix = lintAutofixFlag || lintDiffFlag
+		config.DryRun = lintDiffFlag
+		config.Hints = lintHintsFlag
+		config.Summary = lintSummaryFlag
+		if config.GroupBy, err = lint.ParseGroupBy(lintGroupByFlag); err != nil {
+			return err
+		}
 
-		rules := lint.NewRuleSet()
-		results, err := rules.Lint([]lint.Dashboard{dashboard})
+		// When fixing stdin, the fixed dashboard is written to stdout, so the results go to stderr.
+		stdout := os.Stdout
+		if lintReadFromStdIn && lintAutofixFlag && !lintDiffFlag {
+			stdout = os.Stderr
+		}
+		outputs, closeOutputs, err := openOutputs(lintOutputFlag, stdout)
 		if err != nil {
-			return fmt.Errorf("failed to lint dashboard: %v", err)
+			return err
 		}
+		defer closeOutputs()
 
-		if config.Autofix {
-			changes := results.AutoFix(&dashboard)
-			if changes > 0 {
-				err = write(dashboard, filename, buf)
-				if err != nil {
-					return err
-				}
+		opts := append(outputs, lint.WithConfig(config))
+		if lintLibraryPanelsFlag != "" {
+			lib, err := lint.LoadLibraryPanels(lintLibraryPanelsFlag)
+			if err != nil {
+				return fmt.Errorf("failed to load library panels: %v", err)
 			}
+			opts = append(opts, lint.WithLibraryPanels(lib))
+		}
+		linter, err := lint.NewLinter(opts...)
+		if err != nil {
+			return err
+		}
+		lintFile := linter.LintSource
+		if lintLibraryPanelFlag {
+			lintFile = linter.LintLibraryPanel
+		}
+		result, err := lintFile(filename, buf)
+		if err != nil {
+			return err
+		}
+		if err := linter.Close(); err != nil {
+			return err
 		}
 
-		results.Configure(config)
-		results.ReportByRule()
+		if result.Fixed != nil && lintDiffFlag {
+			name := filename
+			if name == "" {
+				name = "stdin"
+			}
+			diff, err := lint.UnifiedDiff(name, buf, result.Fixed)
+			if err != nil {
+				return err
+			}
+			// The results are written to stdout, so the diff goes to stderr to keep them parseable.
+			fmt.Fprint(os.Stderr, diff)
+			return fmt.Errorf("there are fixes pending, run with --fix to apply them")
+		}
+		if lintReadFromStdIn && lintAutofixFlag && !lintDiffFlag {
+			// The dashboard is written even if nothing was fixed, so the command can be used as a filter.
+			fixed := result.Fixed
+			if fixed == nil {
+				fixed = buf
+			}
+			if _, err := os.Stdout.Write(fixed); err != nil {
+				return err
+			}
+		} else if result.Fixed != nil {
+			if err := os.WriteFile(filename, result.Fixed, 0600); err != nil {
+				return err
+			}
+		}
 
-		if lintStrictFlag && results.MaximumSeverity() >= lint.Warning {
+		if lintStrictFlag && result.Results.MaximumSeverity() >= lint.Warning {
 			return fmt.Errorf("there were linting errors, please see previous output")
 		}
 		return nil
 	},
 }
 
-func write(dashboard lint.Dashboard, filename string, old []byte) error {
-	newBytes, err := dashboard.Marshal()
-	if err != nil {
-		return err
-	}
-	c := conflate.New()
-	err = c.AddData(old, newBytes)
-	if err != nil {
-		return err
-	}
-	b, err := c.MarshalJSON()
-	if err != nil {
-		return err
+// openOutputs creates a reporter option for each --output flag, which has the form format or
+// format=path. Results are written to stdout when no path, or '-', is given. The returned
+// function closes the opened files.
+func openOutputs(specs []string, stdout io.Writer) ([]lint.Option, func(), error) {
+	var opts []lint.Option
+	var files []*os.File
+	closeFiles := func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
 	}
-	json := strings.ReplaceAll(string(b), "\"options\": null,", "\"options\": [],")
 
-	return os.WriteFile(filename, []byte(json), 0600)
+	for _, spec := range specs {
+		format, dest, _ := strings.Cut(spec, "=")
+		reporter, err := lint.NewReporter(format)
+		if err != nil {
+			closeFiles()
+			return nil, nil, err
+		}
+		if dest == "" || dest == "-" {
+			opts = append(opts, lint.WithReporter(reporter, stdout))
+			continue
+		}
+		f, err := os.Create(dest)
+		if err != nil {
+			closeFiles()
+			return nil, nil, fmt.Errorf("failed to create output file %s: %v", dest, err)
+		}
+		files = append(files, f)
+		opts = append(opts, lint.WithReporter(reporter, f))
+	}
+	return opts, closeFiles, nil
 }
 
+var rulesFormatFlag string
+
 var rulesCmd = &cobra.Command{
 	Use:          "rules",
 	Short:        "Print documentation about each lint rule.",
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rules := lint.NewRuleSet()
-		for _, rule := range rules.Rules() {
-			_, _ = fmt.Fprintf(os.Stdout, "* `%s` - %s\n", rule.Name(), rule.Description())
+		docs := rules.Docs()
+		switch rulesFormatFlag {
+		case "text":
+			for _, doc := range docs {
+				_, _ = fmt.Fprintf(os.Stdout, "* `%s` - %s\n", doc.Name, doc.Description)
+			}
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(docs)
+		case "markdown":
+			printRulesMarkdown(os.Stdout, docs)
+		default:
+			return fmt.Errorf("unknown format '%s', must be one of text, json or markdown", rulesFormatFlag)
 		}
 		return nil
 	},
 }
 
+func printRulesMarkdown(w io.Writer, docs []lint.RuleDoc) {
+	_, _ = fmt.Fprintln(w, "| Rule | Description | Category | Datasources | Fixable |")
+	_, _ = fmt.Fprintln(w, "|------|-------------|----------|-------------|---------|")
+	for _, doc := range docs {
+		name := fmt.Sprintf("`%s`", doc.Name)
+		if doc.DocsURL != "" {
+			name = fmt.Sprintf("[%s](%s)", doc.Name, doc.DocsURL)
+		}
+		datasources := "all"
+		if len(doc.Datasources) > 0 {
+			datasources = strings.Join(doc.Datasources, ", ")
+		}
+		fixable := "no"
+		if doc.Fixable {
+			fixable = "yes"
+		}
+		_, _ = fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+			name, strings.ReplaceAll(doc.Description, "|", "\\|"), doc.Category, datasources, fixable)
+	}
+}
+
+var explainCmd = &cobra.Command{
+	Use:          "explain [rule-name]",
+	Short:        "Print the documentation of a lint rule.",
+	Long:         `Prints the rationale, examples, and configuration of a lint rule. The documentation is embedded, so no network access is needed.`,
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rules := lint.NewRuleSet()
+		return rules.Explain(os.Stdout, args[0])
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(lintCmd)
 	rootCmd.AddCommand(rulesCmd)
+	rootCmd.AddCommand(explainCmd)
+	rulesCmd.Flags().StringVar(
+		&rulesFormatFlag,
+		"format",
+		"text",
+		"output format, one of text, json or markdown",
+	)
 	lintCmd.Flags().BoolVar(
 		&lintStrictFlag,
 		"strict",
@@ -148,6 +266,19 @@ func init() {
 		false,
 		"automatically fix problems if possible",
 	)
+	lintCmd.Flags().BoolVar(
+		&lintDiffFlag,
+		"diff",
+		false,
+		"print the fixes as a unified diff instead of writing them, and fail if there are any (alias --dry-run)",
+	)
+	lintCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		// --dry-run is an alias of --diff.
+		if name == "dry-run" {
+			name = "diff"
+		}
+		return pflag.NormalizedName(name)
+	})
 	lintCmd.Flags().StringVarP(
 		&lintConfigFlag,
 		"config",
@@ -155,6 +286,42 @@ func init() {
 		"",
 		"path to a configuration file",
 	)
+	lintCmd.Flags().BoolVar(
+		&lintHintsFlag,
+		"hints",
+		false,
+		"point to the explain command for each failing rule",
+	)
+	lintCmd.Flags().StringArrayVar(
+		&lintOutputFlag,
+		"output",
+		[]string{"tty"},
+		"output format, optionally followed by =path to write to a file, one of "+strings.Join(lint.ReporterFormats(), ", ")+"; may be repeated",
+	)
+	lintCmd.Flags().StringVar(
+		&lintGroupByFlag,
+		"group-by",
+		"rule",
+		"group the tty output by rule, dashboard or panel",
+	)
+	lintCmd.Flags().BoolVar(
+		&lintSummaryFlag,
+		"summary",
+		false,
+		"end the tty output with a table of result counts per rule and dashboard",
+	)
+	lintCmd.Flags().StringVar(
+		&lintLibraryPanelsFlag,
+		"library-panels",
+		"",
+		"directory of exported library panel JSON files to resolve the library panels of the dashboard with",
+	)
+	lintCmd.Flags().BoolVar(
+		&lintLibraryPanelFlag,
+		"library-panel",
+		false,
+		"lint an exported library panel instead of a dashboard, with the panel and target rules",
+	)
 	lintCmd.Flags().BoolVar(
 		&lintReadFromStdIn,
 		"stdin",
@@ -0,0 +1,13 @@
+#! /usr/bin/env bash
+# Replaces the generated rules table in docs/index.md with the output of `rules --format markdown`.
+set -euo pipefail
+
+rules=./docs/_intermediate/rules.md
+index=./docs/index.md
+
+awk -v rules="${rules}" '
+  /<!-- begin rules -->/ { print; while ((getline line < rules) > 0) print line; skip = 1; next }
+  /<!-- end rules -->/ { skip = 0 }
+  !skip { print }
+' "${index}" > "${index}.tmp"
+mv "${index}.tmp" "${index}"